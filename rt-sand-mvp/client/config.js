@@ -82,7 +82,18 @@ export const CONFIG = {
   
   // WebSocket
   WS_RECONNECT_DELAY: 2000,
+
+  // Viewport subscriptions (server chunks are 2^9 = 512 cells wide)
+  CHUNK_BITS: 9,
+  SUBSCRIBE_MARGIN: 64,
+  SUBSCRIBE_INTERVAL: 250,
+
+  // Default replay speed in moves per second, one of the speed menu options
+  REPLAY_SPEED: 10,
   
   // Storage
   STORAGE_KEY: 'infinitego-view',
+  SESSION_KEY: 'infinitego-session',
+  // Owner keys of private rooms we created, suffixed with the room ID
+  OWNER_KEY_PREFIX: 'infinitego-owner-',
 };
@@ -6,6 +6,8 @@ export class Leaderboard {
     this.element = element;
     this.state = state;
     this.colorCounts = {};
+    this.colorStats = {};
+    this.teamCounts = {};
     this.collapsed = false;
     this.dragging = false;
     this.resizing = false;
@@ -237,31 +239,61 @@ export class Leaderboard {
     this.autoAdjustHeight();
   }
 
+  // Counts come from the server's leaderboard; the loaded stones are only
+  // a fallback until it arrives, since they cover just the viewport
   calculateCounts() {
     this.colorCounts = {};
-    for (const stone of this.state.stones.values()) {
-      const color = stone.color;
-      this.colorCounts[color] = (this.colorCounts[color] || 0) + 1;
+    this.colorStats = {};
+    this.teamCounts = {};
+    if (this.state.leaderboard) {
+      for (const stats of this.state.leaderboard.colors || []) {
+        this.colorCounts[stats.color] = stats.stones;
+        this.colorStats[stats.color] = stats;
+      }
+    } else {
+      for (const stone of this.state.stones.values()) {
+        const color = stone.color;
+        this.colorCounts[color] = (this.colorCounts[color] || 0) + 1;
+      }
+    }
+    for (const [color, count] of Object.entries(this.colorCounts)) {
+      const team = this.state.teamOf(Number(color));
+      if (team >= 0) {
+        const entry = this.teamCounts[team] || (this.teamCounts[team] = { colors: [], count: 0 });
+        entry.colors.push(Number(color));
+        entry.count += count;
+      }
     }
   }
 
   render() {
-    const entries = Object.entries(this.colorCounts)
-      .map(([color, count]) => ({ color: Number(color), count }))
-      .sort((a, b) => b.count - a.count);
-
     const displayCount = this.collapsed ? 3 : 10;
+    const teams = Object.entries(this.teamCounts);
+    // In team games, rank teams and show their member colors
+    const entries = teams.length
+      ? teams.map(([team, entry]) => ({ team: Number(team), ...entry }))
+      : Object.entries(this.colorCounts).map(([color, count]) => ({ colors: [Number(color)], count }));
+    entries.sort((a, b) => b.count - a.count);
     const topEntries = entries.slice(0, displayCount);
 
     const listHtml = topEntries.map((entry, index) => {
-      const colorName = CONFIG.COLOR_NAMES[entry.color] || `Color ${entry.color}`;
-      const colorStyle = CONFIG.STONE_COLORS[entry.color] || '#888';
-      
+      const indicators = entry.colors.map(color => {
+        const colorStyle = CONFIG.STONE_COLORS[color] || '#888';
+        return `<span class="color-indicator" style="background-color: ${colorStyle}"></span>`;
+      }).join('');
+      const name = entry.team !== undefined
+        ? `Team ${entry.team + 1}`
+        : CONFIG.COLOR_NAMES[entry.colors[0]] || `Color ${entry.colors[0]}`;
+      const stats = entry.team === undefined && this.colorStats[entry.colors[0]];
+      const title = stats
+        ? `captured ${stats.captured}, lost ${stats.lost}, moves ${stats.moves}`
+        : '';
+
       return `
-        <div class="leaderboard-entry">
+        <div class="leaderboard-entry" title="${title}">
           <span class="rank">${index + 1}.</span>
-          <span class="color-indicator" style="background-color: ${colorStyle}"></span>
-          <span class="color-name">${colorName}</span>
+          ${indicators}
+          <span class="color-name">${name}</span>
           <span class="count">${entry.count}</span>
         </div>
       `;
@@ -1,4 +1,6 @@
 // Lobby application for InfiniteGo room selection
+import { CONFIG } from './config.js';
+
 class LobbyApp {
   constructor() {
     this.selectedColor = 0; // Default to black
@@ -28,7 +30,7 @@ class LobbyApp {
     const createBtn = document.getElementById('create-btn');
     const roomIdInput = document.getElementById('room-id');
 
-    createBtn.addEventListener('click', () => {
+    createBtn.addEventListener('click', async () => {
       let roomId = roomIdInput.value.trim();
       
       // Generate random room ID if not provided
@@ -42,7 +44,52 @@ class LobbyApp {
         return;
       }
 
-      this.joinRoom(roomId);
+      const rules = this.readRules();
+      if (!rules) {
+        alert('同盟格式应为 0,1;2,3，每种颜色只能属于一个同盟，且不能与分队同时使用；同时落子模式不能开启打劫规则');
+        return;
+      }
+
+      const password = document.getElementById('room-password').value;
+      const isPrivate = document.getElementById('room-private').checked || password !== '';
+      let created;
+      try {
+        const response = await fetch('/api/rooms', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({
+            id: roomId,
+            display_name: document.getElementById('room-display-name').value.trim(),
+            owner: document.getElementById('room-owner').value.trim(),
+            rules,
+            max_players: Number(document.getElementById('room-max-players').value),
+            private: isPrivate,
+            password,
+          }),
+        });
+        if (response.status === 409) {
+          alert('房间已存在，请换一个名称或直接加入');
+          return;
+        }
+        if (response.status === 400) {
+          alert(`房间设置无效：${await response.text()}`);
+          return;
+        }
+        if (!response.ok) {
+          throw new Error(await response.text());
+        }
+        created = await response.json();
+      } catch (error) {
+        console.error('Create room error:', error);
+        alert('创建房间失败，请检查服务器连接');
+        return;
+      }
+
+      if (created.owner_key) {
+        // Kept so the game page can make invite links for this room
+        localStorage.setItem(CONFIG.OWNER_KEY_PREFIX + roomId, created.owner_key);
+      }
+      this.joinRoom(roomId, password);
     });
 
     // Allow Enter key to create room
@@ -53,11 +100,45 @@ class LobbyApp {
     });
   }
 
+  // Collect the rule options; returns null if the alliances are malformed
+  // or combined with teams
+  readRules() {
+    const teams = Number(document.getElementById('rule-teams').value);
+    const tickMs = Number(document.getElementById('rule-tick').value);
+    const ko = document.getElementById('rule-ko').value;
+    const alliances = [];
+    const seen = new Set();
+    const text = document.getElementById('rule-alliances').value.trim();
+    for (const group of text ? text.split(';') : []) {
+      const colors = group.split(',').map(s => Number(s.trim()));
+      if (colors.some(c => !Number.isInteger(c) || c < 0 || c > 254 || seen.has(c))) {
+        return null;
+      }
+      colors.forEach(c => seen.add(c));
+      alliances.push(colors);
+    }
+    if (teams > 0 && alliances.length) {
+      return null;
+    }
+    // Simultaneous moves have no order, so ko cannot be judged
+    if (tickMs > 0 && ko !== 'none') {
+      return null;
+    }
+    return {
+      ko,
+      forbid_suicide: document.getElementById('rule-forbid-suicide').checked,
+      self_capture_first: document.getElementById('rule-self-capture-first').checked,
+      alliances,
+      teams,
+      tick_ms: tickMs,
+    };
+  }
+
   setupQuickJoin() {
     const quickJoinBtn = document.getElementById('quick-join-btn');
     const joinRoomIdInput = document.getElementById('join-room-id');
 
-    quickJoinBtn.addEventListener('click', () => {
+    quickJoinBtn.addEventListener('click', async () => {
       const roomId = joinRoomIdInput.value.trim();
       
       if (!roomId) {
@@ -70,7 +151,23 @@ class LobbyApp {
         return;
       }
 
-      this.joinRoom(roomId);
+      // Rooms must be created first; joining a typo would only fail later
+      const password = document.getElementById('join-room-password').value;
+      try {
+        const response = await fetch(`/api/rooms/${encodeURIComponent(roomId)}`);
+        if (response.status === 404) {
+          alert('房间不存在，请先创建');
+          return;
+        }
+        if (response.status === 403 && !password) {
+          alert('这是私密房间，请输入密码或使用邀请链接');
+          return;
+        }
+      } catch (error) {
+        console.error('Look up room error:', error);
+      }
+
+      this.joinRoom(roomId, password);
     });
 
     // Allow Enter key to join room
@@ -132,34 +229,123 @@ class LobbyApp {
     
     card.innerHTML = `
       <div class="room-info">
-        <h3 class="room-name">${this.escapeHtml(room.id)}</h3>
+        <h3 class="room-name">${this.escapeHtml(room.display_name || room.id)}</h3>
+        ${room.display_name && room.display_name !== room.id ? `<p class="room-meta">${this.escapeHtml(room.id)}</p>` : ''}
+        ${room.owner ? `<p class="room-meta">房主：${this.escapeHtml(room.owner)}</p>` : ''}
         <p class="room-players">
           <span class="player-icon">👥</span>
-          ${room.player_count} ${room.player_count === 1 ? '位玩家' : '位玩家'}
+          ${room.player_count}${room.max_players ? ` / ${room.max_players}` : ''} 位玩家
+          ${room.spectator_count ? `· ${room.spectator_count} 位观众` : ''}
+          ${room.hibernating ? '<span class="room-hibernating">休眠中</span>' : ''}
         </p>
+        <p class="room-rules">${this.escapeHtml(this.describeRules(room.rules))}</p>
+        <p class="room-taken">${this.escapeHtml(this.describeTaken(room.taken_colors))}</p>
+      </div>
+      <div class="room-actions">
+        <button class="btn btn-join" data-room-id="${this.escapeHtml(room.id)}">
+          加入房间
+        </button>
+        <button class="btn btn-watch" data-room-id="${this.escapeHtml(room.id)}">
+          观战
+        </button>
+        <button class="btn btn-watch btn-replay" data-room-id="${this.escapeHtml(room.id)}">
+          回放
+        </button>
       </div>
-      <button class="btn btn-join" data-room-id="${this.escapeHtml(room.id)}">
-        加入房间
-      </button>
     `;
 
     const joinBtn = card.querySelector('.btn-join');
     joinBtn.addEventListener('click', () => {
       this.joinRoom(room.id);
     });
+    card.querySelector('.btn-watch:not(.btn-replay)').addEventListener('click', () => {
+      this.watchRoom(room.id);
+    });
+    card.querySelector('.btn-replay').addEventListener('click', () => {
+      this.replayRoom(room.id);
+    });
 
     return card;
   }
 
-  joinRoom(roomId) {
+  describeRules(rules) {
+    if (!rules) {
+      return '';
+    }
+    const parts = [];
+    if (rules.ko === 'simple') parts.push('打劫');
+    if (rules.ko === 'superko') parts.push('全局同形');
+    if (rules.forbid_suicide) parts.push('禁止自杀');
+    if (rules.self_capture_first) parts.push('先判己方');
+    if (rules.tick_ms) parts.push(`同时落子（每 ${rules.tick_ms / 1000} 秒）`);
+    if (rules.teams) parts.push(`${rules.teams} 队（颜色按 c % ${rules.teams} 分队）`);
+    if (rules.alliances && rules.alliances.length) {
+      parts.push('同盟 ' + rules.alliances.map(a => a.join(',')).join(' / '));
+    }
+    return parts.length ? parts.join(' · ') : '自由规则';
+  }
+
+  describeTaken(colors) {
+    if (!colors || !colors.length) {
+      return '';
+    }
+    const names = ['黑', '白', '红', '蓝', '绿', '黄', '紫', '橙', '青', '粉'];
+    return '已占用：' + colors.map(c => names[c] || `颜色 ${c}`).join('、');
+  }
+
+  async joinRoom(roomId, password) {
+    // Private rooms take the password once, in exchange for an access token
+    // that is sent on every connection instead
+    let access = '';
+    if (password) {
+      try {
+        const response = await fetch(`/api/rooms/${encodeURIComponent(roomId)}/access`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ password }),
+        });
+        if (response.status === 403) {
+          alert('密码错误');
+          return;
+        }
+        if (!response.ok) {
+          throw new Error(await response.text());
+        }
+        access = (await response.json()).access;
+      } catch (error) {
+        console.error('Room access error:', error);
+        alert('无法进入房间，请检查服务器连接');
+        return;
+      }
+    }
+    // A full room is joined as a spectator; the server says so with room_full
     // Save room ID and color to session storage
     sessionStorage.setItem('roomId', roomId);
     sessionStorage.setItem('playerColor', this.selectedColor);
+    if (access) {
+      sessionStorage.setItem('roomAccess', access);
+    } else {
+      sessionStorage.removeItem('roomAccess');
+    }
 
     // Navigate to game page
     window.location.href = `index.html?room=${encodeURIComponent(roomId)}`;
   }
 
+  watchRoom(roomId) {
+    // Spectators need no color; the game page connects with role=spectator
+    sessionStorage.setItem('roomId', roomId);
+    sessionStorage.removeItem('roomAccess');
+    window.location.href = `index.html?room=${encodeURIComponent(roomId)}&role=spectator`;
+  }
+
+  replayRoom(roomId) {
+    // Replays start from the empty board and play up to the latest move
+    sessionStorage.setItem('roomId', roomId);
+    sessionStorage.removeItem('roomAccess');
+    window.location.href = `index.html?room=${encodeURIComponent(roomId)}&replay=1`;
+  }
+
   generateRoomId() {
     // Generate a random room ID with format: room-XXXXX
     const chars = 'abcdefghijklmnopqrstuvwxyz0123456789';
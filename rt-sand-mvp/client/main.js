@@ -15,6 +15,24 @@ class InfiniteGoApp {
     const urlParams = new URLSearchParams(window.location.search);
     this.roomId = urlParams.get('room') || sessionStorage.getItem('roomId') || 'default';
     this.playerColor = Number(sessionStorage.getItem('playerColor') || '0');
+    // Spectators watch the board without a color
+    this.spectator = urlParams.get('role') === 'spectator';
+    // Replays play back the room's history; viewers watch like spectators
+    this.replay = urlParams.get('replay') === '1' ? {
+      from: urlParams.get('from') || '',
+      to: urlParams.get('to') || '',
+      speed: Number(urlParams.get('speed') || CONFIG.REPLAY_SPEED),
+    } : null;
+    if (this.replay) {
+      this.spectator = true;
+    }
+    // Private rooms: an invite from the link, or the access token the lobby
+    // got for the password
+    this.auth = {
+      invite: urlParams.get('invite') || sessionStorage.getItem('roomAccess') || '',
+    };
+    // Set when we created this room as a private one
+    this.ownerKey = localStorage.getItem(CONFIG.OWNER_KEY_PREFIX + this.roomId);
     
     // If no room in URL, redirect to lobby
     if (!urlParams.get('room') && !sessionStorage.getItem('roomId')) {
@@ -56,7 +74,22 @@ class InfiniteGoApp {
     this.network = new NetworkManager(this.state, (event, data) => {
       this.handleNetworkEvent(event, data);
     });
-    this.network.connect(this.roomId, this.playerColor);
+    this.network.auth = this.auth;
+    this.network.roomId = this.roomId;
+    this.network.replay = this.replay;
+    if (this.ownerKey && !this.auth.invite) {
+      // Invite-only room we own: let ourselves in with a fresh invite
+      this.network.createInvite(this.ownerKey)
+        .then((inv) => { this.auth.invite = inv.invite; })
+        .catch((err) => console.error('Create invite error:', err))
+        .finally(() => this.network.connect(this.roomId, this.playerColor, this.spectator));
+    } else {
+      this.network.connect(this.roomId, this.playerColor, this.spectator);
+    }
+
+    // Keep the server subscription in step with the visible area
+    this.updateViewport();
+    setInterval(() => this.updateViewport(), CONFIG.SUBSCRIBE_INTERVAL);
 
     // Input manager
     this.input = new InputManager(mainCanvas, this.state, this.renderer, (action, data) => {
@@ -78,11 +111,28 @@ class InfiniteGoApp {
     document.getElementById('current-room').textContent = this.roomId;
     this.updatePlayerColorDisplay();
     
+    // Owners of private rooms can hand out invite links
+    const inviteBtn = document.getElementById('invite-btn');
+    inviteBtn.hidden = !this.ownerKey;
+    inviteBtn.addEventListener('click', async () => {
+      try {
+        const inv = await this.network.createInvite(this.ownerKey);
+        const link = new URL(inv.link, location.href).toString();
+        const expires = new Date(inv.expires_at).toLocaleString();
+        await navigator.clipboard.writeText(link).catch(() => prompt('Invite link', link));
+        this.updateStatus(`Invite link copied, valid until ${expires}`);
+      } catch (err) {
+        console.error('Create invite error:', err);
+        this.updateStatus('Could not create an invite link');
+      }
+    });
+
     // Leave room button
     document.getElementById('leave-room-btn').addEventListener('click', () => {
       if (confirm('Leave this room and return to lobby?')) {
         sessionStorage.removeItem('roomId');
         sessionStorage.removeItem('playerColor');
+        sessionStorage.removeItem('roomAccess');
         window.location.href = 'lobby.html';
       }
     });
@@ -156,8 +206,22 @@ class InfiniteGoApp {
       });
     });
 
+    // Replay speed, 0 pauses
+    const replayControls = document.getElementById('replay-controls');
+    replayControls.hidden = !this.replay;
+    if (this.replay) {
+      const speedSelect = document.getElementById('replay-speed');
+      speedSelect.value = String(this.replay.speed);
+      speedSelect.addEventListener('change', () => {
+        this.replay.speed = Number(speedSelect.value);
+        this.network.setReplaySpeed(this.replay.speed);
+      });
+    }
+
     // Restart button
-    document.getElementById('restart-btn').addEventListener('click', () => {
+    const restartBtn = document.getElementById('restart-btn');
+    restartBtn.hidden = this.spectator;
+    restartBtn.addEventListener('click', () => {
       if (confirm('Clear entire board?')) {
         this.network.sendRestart();
       }
@@ -173,6 +237,12 @@ class InfiniteGoApp {
   updatePlayerColorDisplay() {
     const colorNames = ['Black', 'White', 'Red', 'Blue', 'Green', 'Yellow', 'Purple', 'Orange', 'Cyan', 'Pink'];
     const colorDisplay = document.getElementById('player-color-display');
+    if (colorDisplay && this.spectator) {
+      colorDisplay.textContent = this.replay ? 'Replay' : 'Spectator';
+      colorDisplay.style.backgroundColor = '#888';
+      colorDisplay.style.color = '#fff';
+      return;
+    }
     if (colorDisplay) {
       colorDisplay.textContent = colorNames[this.playerColor] || `Color ${this.playerColor}`;
       
@@ -191,6 +261,19 @@ class InfiniteGoApp {
     }
   }
 
+  updateViewport() {
+    const canvas = document.getElementById('canvas');
+    const topLeft = this.renderer.screenToWorld(0, 0);
+    const bottomRight = this.renderer.screenToWorld(canvas.width, canvas.height);
+    const margin = CONFIG.SUBSCRIBE_MARGIN;
+    this.network.subscribeRegion({
+      minX: topLeft.x - margin,
+      minY: topLeft.y - margin,
+      maxX: bottomRight.x + margin,
+      maxY: bottomRight.y + margin,
+    });
+  }
+
   handleNetworkEvent(event, data) {
     switch (event) {
       case 'status':
@@ -203,6 +286,38 @@ class InfiniteGoApp {
         this.leaderboard.update();
         break;
       
+      case 'session':
+        this.playerColor = data.color;
+        sessionStorage.setItem('playerColor', data.color);
+        this.updatePlayerColorDisplay();
+        this.updateStatus('Rejoined with your color');
+        break;
+
+      case 'spectating':
+        this.spectator = true;
+        this.updatePlayerColorDisplay();
+        document.getElementById('restart-btn').hidden = true;
+        this.updateStatus('The room is full, watching as a spectator');
+        break;
+
+      case 'closed': {
+        const whats = { 4001: 'The room was closed', 4002: 'The room is full' };
+        const what = whats[data.code] || 'You were removed from the room';
+        alert(data.reason ? `${what}: ${data.reason}` : what);
+        sessionStorage.removeItem('roomId');
+        window.location.href = 'lobby.html';
+        break;
+      }
+
+      case 'replay_done':
+        this.updateStatus('Replay finished');
+        break;
+
+      case 'room_info':
+      case 'leaderboard':
+        this.leaderboard.update();
+        break;
+
       case 'restart':
         this.updateStatus('Cleared your stones');
         this.leaderboard.update();
@@ -213,6 +328,10 @@ class InfiniteGoApp {
   handleInputAction(action, data) {
     switch (action) {
       case 'place_stone':
+        if (this.spectator) {
+          this.updateStatus('Spectators cannot place stones');
+          break;
+        }
         this.network.sendMove(data.x, data.y, data.color);
         break;
     }
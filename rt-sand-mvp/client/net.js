@@ -1,5 +1,6 @@
 // Network communication for InfiniteGo
 import { CONFIG } from './config.js';
+import { chunkKey } from './state.js';
 
 export class NetworkManager {
   constructor(state, onStateUpdate) {
@@ -9,9 +10,16 @@ export class NetworkManager {
     this.connecting = false;
     this.roomId = null;
     this.playerColor = null;
+    this.spectator = false;
+    // Invite or access token of a private room, sent on every connection
+    this.auth = {};
+    // Set to { from, to, speed } to watch the room's history instead
+    this.replay = null;
+    this.region = null;
+    this.regionKey = null;
   }
 
-  connect(roomId, playerColor) {
+  connect(roomId, playerColor, spectator) {
     if (this.connecting || (this.ws && this.ws.readyState === WebSocket.OPEN)) {
       return;
     }
@@ -19,31 +27,77 @@ export class NetworkManager {
     // Store room and color info
     this.roomId = roomId || 'default';
     this.playerColor = playerColor !== undefined ? playerColor : 0;
+    this.spectator = !!spectator;
 
     this.connecting = true;
     const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
     // Add room parameter to WebSocket URL
-    const wsUrl = `${protocol}//${location.host}/ws?room=${encodeURIComponent(this.roomId)}`;
+    let wsUrl = `${protocol}//${location.host}/ws?room=${encodeURIComponent(this.roomId)}`;
+    if (this.auth.invite) {
+      wsUrl += `&invite=${encodeURIComponent(this.auth.invite)}`;
+    }
+    if (this.replay) {
+      // Replays stream the history from the journal and take no commands
+      wsUrl += `&replay=1&speed=${this.replay.speed}`;
+      if (this.replay.from) {
+        wsUrl += `&from=${encodeURIComponent(this.replay.from)}`;
+      }
+      if (this.replay.to) {
+        wsUrl += `&to=${encodeURIComponent(this.replay.to)}`;
+      }
+    } else if (this.spectator) {
+      // Spectators get the board but no session or color
+      wsUrl += '&role=spectator';
+    } else {
+      // Watch instead of failing when every player slot is taken
+      wsUrl += '&fallback=spectator';
+      // Resume our session so the server gives our seat back
+      const token = localStorage.getItem(CONFIG.SESSION_KEY);
+      if (token) {
+        wsUrl += `&session=${encodeURIComponent(token)}`;
+      }
+    }
 
     this.ws = new WebSocket(wsUrl);
     
     this.ws.onopen = () => {
       console.log('WebSocket connected to room:', this.roomId);
       this.connecting = false;
+      if (this.replay) {
+        // The server sends the starting board by itself
+        this.onStateUpdate('status', `Replaying room: ${this.roomId}`);
+        return;
+      }
       
-      // Send color selection first
-      this.sendColorSelection(this.playerColor);
+      // The color is claimed once the server sends our session
+      this.send({ type: 'get_leaderboard' });
       
-      // Then request initial state
-      this.requestState();
+      // Request initial state for the viewport (or whole board)
+      this.regionKey = null;
+      if (this.region) {
+        this.subscribeRegion(this.region);
+      } else {
+        this.requestState();
+      }
       this.onStateUpdate('status', `Connected to room: ${this.roomId}`);
     };
 
-    this.ws.onclose = () => {
+    this.ws.onclose = (event) => {
       console.log('WebSocket disconnected');
       this.connecting = false;
+      // Kicked (4000), room closed (4001) or full (4002): reconnecting
+      // would not help
+      if (event.code === 4000 || event.code === 4001 || event.code === 4002) {
+        this.onStateUpdate('closed', { code: event.code, reason: event.reason });
+        return;
+      }
+      if (this.replay) {
+        // Reconnecting would start the replay over
+        this.onStateUpdate('status', 'Replay disconnected');
+        return;
+      }
       this.onStateUpdate('status', 'Disconnected. Reconnecting...');
-      setTimeout(() => this.connect(this.roomId, this.playerColor), CONFIG.WS_RECONNECT_DELAY);
+      setTimeout(() => this.connect(this.roomId, this.playerColor, this.spectator), CONFIG.WS_RECONNECT_DELAY);
     };
 
     this.ws.onerror = (err) => {
@@ -70,6 +124,27 @@ export class NetworkManager {
         }
         break;
 
+      case 'session':
+        if (msg.session) {
+          localStorage.setItem(CONFIG.SESSION_KEY, msg.session.token);
+          if (msg.session.color !== undefined) {
+            // Reconnected to our seat; the server's color wins
+            this.playerColor = msg.session.color;
+            this.state.selectedColor = msg.session.color;
+            this.onStateUpdate('session', msg.session);
+          } else {
+            this.sendColorSelection(this.playerColor);
+          }
+        }
+        break;
+
+      case 'room_info':
+        if (msg.room_info) {
+          this.state.rules = msg.room_info.rules || null;
+          this.onStateUpdate('room_info', msg.room_info);
+        }
+        break;
+
       case 'delta_update':
         if (msg.delta_update) {
           this.state.applyDelta(msg.delta_update);
@@ -84,14 +159,48 @@ export class NetworkManager {
         }
         break;
 
+      case 'chunk_state':
+        if (msg.chunk_state) {
+          this.state.applyChunkState(msg.chunk_state);
+          this.onStateUpdate('board_state', msg.chunk_state);
+        }
+        break;
+
+      case 'leaderboard':
+        if (msg.leaderboard) {
+          this.state.leaderboard = msg.leaderboard;
+          this.onStateUpdate('leaderboard', msg.leaderboard);
+        }
+        break;
+
+      case 'score':
+        if (msg.score) {
+          this.onStateUpdate('score', msg.score);
+        }
+        break;
+
       case 'move_result':
-        if (msg.move_result && !msg.move_result.accepted) {
+        if (msg.move_result && msg.move_result.reason === 'cooldown') {
+          const seconds = (msg.move_result.retry_after_ms / 1000).toFixed(1);
+          this.onStateUpdate('status', `Too fast, wait ${seconds}s`);
+        } else if (msg.move_result && msg.move_result.reason === 'room_full') {
+          // The server let us in as a spectator; stay one across reconnects
+          this.spectator = true;
+          this.onStateUpdate('spectating');
+        } else if (msg.move_result && msg.move_result.reason === 'color_taken') {
+          const free = (msg.move_result.free_colors || []).join(', ');
+          this.onStateUpdate('status', `Color is held by another player; free colors: ${free || 'none'}`);
+        } else if (msg.move_result && !msg.move_result.accepted) {
           this.onStateUpdate('status', `Move failed: ${msg.move_result.reason || 'unknown'}`);
         } else if (msg.move_result && msg.move_result.accepted) {
           this.onStateUpdate('status', 'Move accepted');
         }
         break;
 
+      case 'replay_done':
+        this.onStateUpdate('replay_done');
+        break;
+
       case 'restart':
         this.state.clearStones();
         this.state.seq = 0n;
@@ -103,13 +212,71 @@ export class NetworkManager {
     }
   }
 
+  // Colors run 0-254; the server keeps 255 to mark empty points
   sendColorSelection(color) {
+    color = Number(color);
+    if (!Number.isInteger(color) || color < 0 || color > 254) {
+      console.warn('Invalid color:', color);
+      return;
+    }
     this.send({
       type: 'select_color',
-      color: Number(color),
+      color,
     });
   }
 
+  // Subscribe to the chunks covering a cell rectangle. Only re-sends when
+  // the set of chunks changes, so it is cheap to call on every pan.
+  subscribeRegion(region) {
+    this.region = region;
+    if (this.replay) {
+      // A replay always covers the whole board
+      return;
+    }
+    const shift = CONFIG.CHUNK_BITS;
+    const cx0 = Math.floor(region.minX / 2 ** shift);
+    const cy0 = Math.floor(region.minY / 2 ** shift);
+    const cx1 = Math.floor(region.maxX / 2 ** shift);
+    const cy1 = Math.floor(region.maxY / 2 ** shift);
+    const key = `${cx0},${cy0}:${cx1},${cy1}`;
+    if (key === this.regionKey || !this.ws || this.ws.readyState !== WebSocket.OPEN) {
+      return;
+    }
+    this.regionKey = key;
+
+    const keys = new Set();
+    for (let cx = cx0; cx <= cx1; cx++) {
+      for (let cy = cy0; cy <= cy1; cy++) {
+        keys.add(chunkKey(cx, cy));
+      }
+    }
+    this.state.retainChunks(keys);
+    this.send({
+      type: 'subscribe_region',
+      min_x: String(region.minX),
+      min_y: String(region.minY),
+      max_x: String(region.maxX),
+      max_y: String(region.maxY),
+    });
+  }
+
+  // Fetch specific chunks; each arrives as a separate chunk_state message
+  requestChunks(chunks) {
+    this.send({ type: 'get_chunks', chunks });
+  }
+
+  // Ask for area scores of a cell rectangle, or of the whole board
+  requestScore(region) {
+    const msg = { type: 'get_score' };
+    if (region) {
+      msg.min_x = String(region.minX);
+      msg.min_y = String(region.minY);
+      msg.max_x = String(region.maxX);
+      msg.max_y = String(region.maxY);
+    }
+    this.send(msg);
+  }
+
   requestState() {
     this.send({ type: 'get_state' });
   }
@@ -122,6 +289,22 @@ export class NetworkManager {
     });
   }
 
+  async createInvite(ownerKey) {
+    const response = await fetch(`/api/rooms/${encodeURIComponent(this.roomId)}/invites`, {
+      method: 'POST',
+      headers: { Authorization: `Bearer ${ownerKey}` },
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    return response.json();
+  }
+
+  // Change replay speed in moves per second; 0 pauses
+  setReplaySpeed(speed) {
+    this.send({ type: 'replay_speed', speed: Number(speed) });
+  }
+
   sendRestart() {
     this.send({ type: 'restart' });
   }
@@ -1,6 +1,55 @@
 // State management for InfiniteGo
 import { CONFIG } from './config.js';
 
+export function chunkKey(cx, cy) {
+  return `${cx},${cy}`;
+}
+
+function stoneChunkKey(stone) {
+  const shift = BigInt(CONFIG.CHUNK_BITS);
+  return chunkKey(stone.x >> shift, stone.y >> shift);
+}
+
+// Decode a packed chunk (see server/packed.go) into cells. Deflated chunks
+// are only used in storage, so the server never sends them.
+export function unpackChunk(packed) {
+  const bytes = Uint8Array.from(atob(packed.data), ch => ch.charCodeAt(0));
+  if (bytes[0] !== 0) {
+    throw new Error('unsupported packed chunk flags');
+  }
+  const bits = BigInt(CONFIG.CHUNK_BITS);
+  const mask = (1 << CONFIG.CHUNK_BITS) - 1;
+  const baseX = BigInt(packed.x) << bits;
+  const baseY = BigInt(packed.y) << bits;
+  const cells = [];
+  let pos = 1;
+  const uvarint = () => {
+    let value = 0;
+    let scale = 1;
+    while (bytes[pos] & 0x80) {
+      value += (bytes[pos++] & 0x7f) * scale;
+      scale *= 128;
+    }
+    return value + bytes[pos++] * scale;
+  };
+  let next = 0;
+  while (pos < bytes.length) {
+    const head = uvarint();
+    const length = head % 2 ? uvarint() + 2 : 1;
+    const color = bytes[pos++];
+    const start = next + Math.floor(head / 2);
+    for (let idx = start; idx < start + length; idx++) {
+      cells.push({
+        x: baseX + BigInt(idx >> CONFIG.CHUNK_BITS),
+        y: baseY + BigInt(idx & mask),
+        color,
+      });
+    }
+    next = start + length;
+  }
+  return cells;
+}
+
 export class GameState {
   constructor() {
     this.stones = new Map();
@@ -10,6 +59,8 @@ export class GameState {
     this.pan = { x: 0, y: 0 };
     this.placementMode = 'intersection';
     this.selectedColor = 0; // ColorBlack
+    this.rules = null; // room rules from room_info
+    this.leaderboard = null; // per-color counters pushed by the server
     
     this.loadViewState();
   }
@@ -51,6 +102,19 @@ export class GameState {
     this.stones.delete(`${x},${y}`);
   }
 
+  // Team or alliance index of a color, or -1 if it plays alone. Mirrors
+  // RuleSet.TeamOf on the server.
+  teamOf(color) {
+    const rules = this.rules;
+    if (!rules) {
+      return -1;
+    }
+    if (rules.teams > 0) {
+      return color % rules.teams;
+    }
+    return (rules.alliances || []).findIndex(team => team.includes(color));
+  }
+
   clearStones() {
     this.stones.clear();
   }
@@ -67,10 +131,42 @@ export class GameState {
 
   applyBoardState(state) {
     this.seq = BigInt(state.server_seq);
-    this.clearStones();
+    if (state.chunks) {
+      // Partial state: only replace the listed chunks
+      const keys = new Set(state.chunks.map(c => chunkKey(c.x, c.y)));
+      for (const [key, stone] of this.stones) {
+        if (keys.has(stoneChunkKey(stone))) {
+          this.stones.delete(key);
+        }
+      }
+    } else {
+      this.clearStones();
+    }
     for (const cell of state.cells || []) {
       this.addStone(cell.x, cell.y, cell.color);
     }
+    for (const packed of state.packed || []) {
+      for (const cell of unpackChunk(packed)) {
+        this.addStone(cell.x, cell.y, cell.color);
+      }
+    }
+  }
+
+  applyChunkState(chunk) {
+    this.applyBoardState({
+      cells: chunk.cells,
+      chunks: [{ x: chunk.x, y: chunk.y }],
+      server_seq: chunk.server_seq,
+    });
+  }
+
+  // Drop stones outside the subscribed chunks; they would go stale
+  retainChunks(keys) {
+    for (const [key, stone] of this.stones) {
+      if (!keys.has(stoneChunkKey(stone))) {
+        this.stones.delete(key);
+      }
+    }
   }
 
   resetView() {
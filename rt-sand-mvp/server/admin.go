@@ -0,0 +1,224 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket close codes sent when the server ends a connection on purpose.
+// Clients do not reconnect after these.
+const (
+	CloseKicked     = 4000
+	CloseRoomClosed = 4001
+)
+
+// ErrNoStore is returned for persistence requests on a room without a store
+var ErrNoStore = errors.New("room has no store")
+
+// Admin request kinds
+const (
+	adminReset    = "reset"
+	adminKick     = "kick"
+	adminSnapshot = "snapshot"
+)
+
+// AdminRequest asks the room goroutine to reset the board, kick a player
+// or write a snapshot. Kicks target a session, or the session holding Color.
+type AdminRequest struct {
+	Kind    string
+	Session string
+	Color   *Color
+	Reason  string
+	Reply   chan AdminReply
+}
+
+type AdminReply struct {
+	Kicked    int    `json:"kicked,omitempty"`
+	ServerSeq uint64 `json:"server_seq"`
+	Err       error  `json:"-"`
+}
+
+// AdminBody is the body of the admin endpoints; each uses the fields it needs
+type AdminBody struct {
+	Reason  string `json:"reason"`
+	Session string `json:"session"`
+	Color   *int   `json:"color"`
+}
+
+// GetAdminToken reads the bearer token of the admin API; empty disables it
+func GetAdminToken() string {
+	return getEnv("ADMIN_TOKEN", "")
+}
+
+// serveAdmin runs an admin request on the room goroutine. snapQ is nil for
+// rooms without a store.
+func (r *Room) serveAdmin(req AdminRequest, snapQ chan<- snapshotJob) {
+	reply := AdminReply{}
+	switch req.Kind {
+	case adminReset:
+		delta := r.ResetBoard()
+		r.noteChange(snapQ)
+		r.broadcast(delta)
+	case adminKick:
+		reply.Kicked, reply.Err = r.kick(req)
+	case adminSnapshot:
+		if r.store == nil {
+			reply.Err = ErrNoStore
+			break
+		}
+		// Written right away so the reply can report failures
+		if reply.Err = r.saveSnapshot(); reply.Err == nil {
+			r.changesSinceSnapshot = 0
+		}
+	}
+	reply.ServerSeq = r.Seq
+	req.Reply <- reply
+}
+
+// kick closes every connection of the target session and frees its color
+func (r *Room) kick(req AdminRequest) (int, error) {
+	token := req.Session
+	if req.Color != nil {
+		seat, ok := r.seats[*req.Color]
+		if !ok {
+			return 0, errors.New("color is not held")
+		}
+		token = seat.SessionID
+	}
+	if token == "" {
+		return 0, errors.New("session or color required")
+	}
+	if seat := r.seatOf(token); seat != nil {
+		delete(r.seats, seat.Color)
+		r.seatsChanged()
+	}
+	kicked := 0
+	for c, t := range r.conns {
+		if t == token {
+			c.closeWith(CloseKicked, req.Reason)
+			kicked++
+		}
+	}
+	return kicked, nil
+}
+
+// closeWith ends a connection with a close code and reason. It may be called
+// from any goroutine, including the room's: the close frame is written in the
+// background so a slow client cannot stall the caller. readPump notices and
+// cleans up.
+func (c *Client) closeWith(code int, reason string) {
+	if c.conn == nil {
+		return
+	}
+	// Control frames carry at most 123 bytes of reason
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	msg := websocket.FormatCloseMessage(code, reason)
+	go func() {
+		c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		c.conn.Close()
+	}()
+}
+
+// closeClients disconnects everyone in the room
+func (r *Room) closeClients(code int, reason string) {
+	r.clMu.RLock()
+	defer r.clMu.RUnlock()
+	for c := range r.clients {
+		c.closeWith(code, reason)
+	}
+}
+
+// ServeAdmin handles the admin API, which needs "Authorization: Bearer
+// <token>":
+//
+//	POST /api/admin/rooms                 create a room, body as POST /api/rooms
+//	POST /api/admin/rooms/{id}/close      disconnect everyone and delete the room
+//	POST /api/admin/rooms/{id}/reset      clear the whole board
+//	POST /api/admin/rooms/{id}/kick       disconnect a session or color holder
+//	POST /api/admin/rooms/{id}/snapshot   write a snapshot now
+func ServeAdmin(roomManager *RoomManager, token string, w http.ResponseWriter, r *http.Request) {
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/rooms"), "/"), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		createRoom(roomManager, w, r)
+		return
+	}
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	var body AdminBody
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	roomID := parts[0]
+	if parts[1] == "close" {
+		err := roomManager.CloseRoom(roomID, body.Reason)
+		switch {
+		case errors.Is(err, ErrRoomNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	room, ok := roomManager.GetRoom(roomID)
+	if !ok {
+		http.Error(w, "room not found", http.StatusNotFound)
+		return
+	}
+	req := AdminRequest{Kind: parts[1], Session: body.Session, Reason: body.Reason, Reply: make(chan AdminReply, 1)}
+	switch req.Kind {
+	case adminReset, adminSnapshot:
+	case adminKick:
+		if body.Color != nil {
+			if *body.Color < 0 || *body.Color >= int(emptyCell) {
+				http.Error(w, ErrInvalidColor.Error(), http.StatusBadRequest)
+				return
+			}
+			color := Color(*body.Color)
+			req.Color = &color
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	reply, ok := askRoom(r, room.AdminInbox, req, req.Reply)
+	if !ok {
+		http.Error(w, "room busy", http.StatusServiceUnavailable)
+		return
+	}
+	if reply.Err != nil {
+		http.Error(w, reply.Err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, reply)
+}
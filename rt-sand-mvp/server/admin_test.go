@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// adminServer serves /ws and the admin API with the token "secret"
+func adminServer(t *testing.T, store Store) (*RoomManager, *httptest.Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			ServeWS(rm, w, r)
+			return
+		}
+		ServeAdmin(rm, "secret", w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return rm, srv
+}
+
+func adminPost(t *testing.T, srv *httptest.Server, path, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// joinWithColor connects to a room and claims a color
+func joinWithColor(t *testing.T, srv *httptest.Server, room string, color Color) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=" + room
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.WriteJSON(map[string]interface{}{"type": "select_color", "color": int(color)})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var env Envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == "color_selected" {
+			return conn
+		}
+	}
+}
+
+// closeCode reads until the server closes the connection
+func closeCode(t *testing.T, conn *websocket.Conn) (int, string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var ce *websocket.CloseError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected a close frame, got %v", err)
+			}
+			return ce.Code, ce.Text
+		}
+	}
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	_, srv := adminServer(t, nil)
+	if resp := adminPost(t, srv, "/api/admin/rooms", "", `{"id":"x"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms", "wrong", `{"id":"x"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong token, got %d", resp.StatusCode)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms", "secret", `{"id":"x","rules":{"ko":"simple"}}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	rec := httptest.NewRecorder()
+	ServeAdmin(nil, "", rec, httptest.NewRequest(http.MethodPost, "/api/admin/rooms", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("admin API should be off without a token, got %d", rec.Code)
+	}
+}
+
+func TestAdminKickFreesColor(t *testing.T) {
+	rm, srv := adminServer(t, nil)
+	rm.CreateRoom("kick", RoomSettings{})
+	conn := joinWithColor(t, srv, "kick", ColorRed)
+
+	resp := adminPost(t, srv, "/api/admin/rooms/kick/kick", "secret", `{"color":2,"reason":"spamming"}`)
+	var reply AdminReply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Kicked != 1 {
+		t.Fatalf("expected one kicked connection, got %+v: %v", reply, err)
+	}
+	if code, reason := closeCode(t, conn); code != CloseKicked || reason != "spamming" {
+		t.Fatalf("unexpected close %d %q", code, reason)
+	}
+	room, _ := rm.GetRoom("kick")
+	if taken := room.Info().TakenColors; len(taken) != 0 {
+		t.Fatalf("kicked color should be free, got %v", taken)
+	}
+}
+
+func TestAdminCloseRoom(t *testing.T) {
+	store := NewMemoryStore()
+	rm, srv := adminServer(t, store)
+	rm.CreateRoom("doomed", RoomSettings{})
+	conn := joinWithColor(t, srv, "doomed", ColorBlue)
+	room, _ := rm.GetRoom("doomed")
+
+	conn.WriteJSON(map[string]interface{}{"type": "move", "x": 1, "y": 1, "color": int(ColorBlue)})
+	var env Envelope
+	if err := conn.ReadJSON(&env); err != nil || env.MoveResult == nil || !env.MoveResult.Accepted {
+		t.Fatalf("move: %+v %v", env, err)
+	}
+	for _, action := range []string{"snapshot", "reset"} {
+		if resp := adminPost(t, srv, "/api/admin/rooms/doomed/"+action, "secret", ""); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: %d", action, resp.StatusCode)
+		}
+	}
+	if snaps, _ := store.ListSnapshots("doomed"); len(snaps) != 2 || snaps[1].ServerSeq != 1 {
+		t.Fatalf("expected a forced snapshot at seq 1, got %+v", snaps)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms/doomed/close", "secret", `{"reason":"maintenance"}`); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("close: %d", resp.StatusCode)
+	}
+	if code, reason := closeCode(t, conn); code != CloseRoomClosed || reason != "maintenance" {
+		t.Fatalf("unexpected close %d %q", code, reason)
+	}
+	select {
+	case <-room.done:
+	default:
+		t.Fatalf("room goroutine still running")
+	}
+	if _, ok := rm.GetRoom("doomed"); ok {
+		t.Fatalf("room still listed")
+	}
+	if _, err := store.LoadRoom("doomed"); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("saved room should be deleted, got %v", err)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms/doomed/reset", "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a closed room, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdminClosesSavedRoom(t *testing.T) {
+	store := NewMemoryStore()
+	_, srv := adminServer(t, store)
+	// Saved behind the manager's back, so it is neither live nor listed
+	saved := NewRoom()
+	if err := saved.AttachStore("dormant", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms/dormant/close", "secret", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("close: %d", resp.StatusCode)
+	}
+	if _, err := store.LoadRoom("dormant"); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("saved room should be deleted, got %v", err)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms/dormant/close", "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing room, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,396 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// apiTimeout bounds how long an HTTP handler waits on a room goroutine
+const apiTimeout = 5 * time.Second
+
+// roomIDPattern is the naming rule from docs/Rooms.md
+var roomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+// Length limits of the free-text room fields, in characters
+const (
+	MaxDisplayNameLength = 64
+	MaxOwnerLength       = 32
+)
+
+// validLabel accepts printable text of at most max characters
+func validLabel(s string, max int) bool {
+	if !utf8.ValidString(s) || utf8.RuneCountInString(s) > max {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateRoomRequest is the body of POST /api/rooms
+type CreateRoomRequest struct {
+	ID          string   `json:"id"`
+	DisplayName string   `json:"display_name"` // the ID when empty
+	Owner       string   `json:"owner"`
+	Rules       *RuleSet `json:"rules"`       // omitted for the server default
+	MaxPlayers  int      `json:"max_players"` // 0 for the server default
+	// Private rooms are unlisted and need Password or an invite; a
+	// password alone makes the room private too
+	Private  bool   `json:"private"`
+	Password string `json:"password"`
+}
+
+// CreatedRoom answers POST /api/rooms. The owner key of a private room is
+// only ever sent here; it is needed to make invites.
+type CreatedRoom struct {
+	RoomInfo
+	OwnerKey string `json:"owner_key,omitempty"`
+}
+
+// ServeRooms handles /api/rooms: GET lists live rooms, POST creates one
+func ServeRooms(roomManager *RoomManager, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, roomManager.GetRoomInfoList())
+	case http.MethodPost:
+		createRoom(roomManager, w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createRoom handles a CreateRoomRequest body
+func createRoom(roomManager *RoomManager, w http.ResponseWriter, r *http.Request) {
+	var req CreateRoomRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !roomIDPattern.MatchString(req.ID) {
+		http.Error(w, "invalid room id", http.StatusBadRequest)
+		return
+	}
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	req.Owner = strings.TrimSpace(req.Owner)
+	if !validLabel(req.DisplayName, MaxDisplayNameLength) {
+		http.Error(w, "invalid display name", http.StatusBadRequest)
+		return
+	}
+	if !validLabel(req.Owner, MaxOwnerLength) {
+		http.Error(w, "invalid owner", http.StatusBadRequest)
+		return
+	}
+	settings := RoomSettings{
+		DisplayName: req.DisplayName,
+		Owner:       req.Owner,
+		Rules:       req.Rules,
+		MaxPlayers:  req.MaxPlayers,
+	}
+	var ownerKey string
+	if req.Private || req.Password != "" {
+		var err error
+		if settings.Access, ownerKey, err = newRoomAccess(req.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	room, err := roomManager.CreateRoom(req.ID, settings)
+	switch {
+	case errors.Is(err, ErrRoomExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, CreatedRoom{RoomInfo: room.Info(), OwnerKey: ownerKey})
+}
+
+// ServeRoomAPI routes GET /api/rooms/{id} and the per-room endpoints under
+// it
+func ServeRoomAPI(roomManager *RoomManager, w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/rooms/"), "/"), "/")
+	if parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	room, err := roomManager.OpenRoom(parts[0])
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Owners make invites with their key and the password buys an access
+	// token; everything else needs an invite or access token, so the bcrypt
+	// check runs once and the password stays out of URLs
+	if len(parts) == 2 && parts[1] == "invites" {
+		serveInvite(room, w, r)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "access" {
+		serveAccess(room, w, r)
+		return
+	}
+	if !room.Access.admits(room.ID, "", r.URL.Query().Get("invite"), time.Now()) {
+		http.Error(w, ErrAccessDenied.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		serveRoomInfo(room, w, r)
+	case parts[1] == "chunks" && len(parts) == 4:
+		serveChunk(room, parts[2], parts[3], w, r)
+	case parts[1] == "score" && len(parts) == 2:
+		serveScore(room, w, r)
+	case parts[1] == "leaderboard" && len(parts) == 2:
+		serveLeaderboard(room, w, r)
+	case parts[1] == "replay" && len(parts) == 2:
+		serveReplay(room, w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveRoomInfo handles GET /api/rooms/{id}: the room's settings, who made
+// it and when, and who is in it
+func serveRoomInfo(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, room.Info())
+}
+
+// serveChunk handles GET /api/rooms/{id}/chunks/{cx}/{cy}
+func serveChunk(room *Room, cxs, cys string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cx, errX := strconv.ParseInt(cxs, 10, 32)
+	cy, errY := strconv.ParseInt(cys, 10, 32)
+	if errX != nil || errY != nil {
+		http.Error(w, "invalid chunk coordinate", http.StatusBadRequest)
+		return
+	}
+
+	reply := make(chan []ChunkState, 1)
+	req := ChunksRequest{IDs: []ChunkID{{X: int32(cx), Y: int32(cy)}}, Reply: reply}
+	states, ok := askRoom(r, room.ChunksInbox, req, reply)
+	if !ok {
+		http.Error(w, "room busy", http.StatusServiceUnavailable)
+		return
+	}
+	state := states[0]
+
+	etag := state.ETag()
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Chunk-Version", strconv.FormatUint(state.Version, 10))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, state)
+}
+
+// serveScore handles GET /api/rooms/{id}/score. Without min_x, min_y,
+// max_x and max_y the bounding box of all stones is scored.
+func serveScore(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	req := ScoreRequest{Reply: make(chan ScoreReply, 1)}
+	if q.Has("min_x") || q.Has("min_y") || q.Has("max_x") || q.Has("max_y") {
+		rect, err := parseRect(json.Number(q.Get("min_x")), json.Number(q.Get("min_y")),
+			json.Number(q.Get("max_x")), json.Number(q.Get("max_y")))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Region = &rect
+	}
+	reply, ok := askRoom(r, room.ScoreInbox, req, req.Reply)
+	if !ok {
+		http.Error(w, "room busy", http.StatusServiceUnavailable)
+		return
+	}
+	if reply.Err != nil {
+		http.Error(w, reply.Err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, reply.Score)
+}
+
+// serveLeaderboard handles GET /api/rooms/{id}/leaderboard
+func serveLeaderboard(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reply := make(chan Leaderboard, 1)
+	board, ok := askRoom(r, room.BoardInbox, LeaderboardRequest{Reply: reply}, reply)
+	if !ok {
+		http.Error(w, "room busy", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, board)
+}
+
+// serveReplay handles GET /api/rooms/{id}/replay?from=&to=. With from
+// equal to to it returns just the board at that seq.
+func serveReplay(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	replay, err := room.replayQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), replayStatus(err))
+		return
+	}
+	writeJSON(w, replay)
+}
+
+// replayStatus maps a Replay error to an HTTP status
+func replayStatus(err error) int {
+	var tooEarly *ReplayTooEarlyError
+	if errors.Is(err, ErrReplayRange) || errors.Is(err, ErrReplayTooFar) || errors.Is(err, ErrNoStore) || errors.As(err, &tooEarly) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// InviteRequest is the optional body of POST /api/rooms/{id}/invites
+type InviteRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds"` // 0 for DefaultInviteTTL
+}
+
+// Invite is a signed token that admits its holder to a private room until
+// ExpiresAt. Link is the game page path that uses it.
+type Invite struct {
+	Invite    string    `json:"invite"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Link      string    `json:"link"`
+}
+
+// serveInvite handles POST /api/rooms/{id}/invites, which needs
+// "Authorization: Bearer <owner key>"
+func serveInvite(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !room.Access.Private {
+		http.Error(w, "room is public", http.StatusBadRequest)
+		return
+	}
+	if !room.Access.isOwner(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+		http.Error(w, ErrNotOwner.Error(), http.StatusForbidden)
+		return
+	}
+	var req InviteRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ttl := DefaultInviteTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > MaxInviteTTL {
+		http.Error(w, "ttl_seconds out of range", http.StatusBadRequest)
+		return
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	token := room.Access.invite(room.ID, expires)
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, Invite{
+		Invite:    token,
+		ExpiresAt: expires,
+		Link:      "/index.html?room=" + url.QueryEscape(room.ID) + "&invite=" + url.QueryEscape(token),
+	})
+}
+
+// AccessRequest is the body of POST /api/rooms/{id}/access
+type AccessRequest struct {
+	Password string `json:"password"`
+}
+
+// AccessToken admits its holder to a private room until ExpiresAt. It is
+// signed like an invite and is used the same way, as invite=.
+type AccessToken struct {
+	Access    string    `json:"access"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// serveAccess handles POST /api/rooms/{id}/access, which trades the room
+// password for an access token
+func serveAccess(room *Room, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req AccessRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if !room.Access.admits(room.ID, req.Password, "", now) {
+		http.Error(w, ErrAccessDenied.Error(), http.StatusForbidden)
+		return
+	}
+	expires := now.Add(AccessTokenTTL).Truncate(time.Second)
+	writeJSON(w, AccessToken{Access: room.Access.invite(room.ID, expires), ExpiresAt: expires})
+}
+
+// askRoom sends a request to a room inbox and waits for its reply
+func askRoom[Req, Resp any](r *http.Request, inbox chan<- Req, req Req, reply <-chan Resp) (Resp, bool) {
+	var zero Resp
+	timeout := time.NewTimer(apiTimeout)
+	defer timeout.Stop()
+	select {
+	case inbox <- req:
+	case <-r.Context().Done():
+		return zero, false
+	case <-timeout.C:
+		return zero, false
+	}
+	select {
+	case resp := <-reply:
+		return resp, true
+	case <-r.Context().Done():
+		return zero, false
+	case <-timeout.C:
+		return zero, false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunkEndpointSupportsRevalidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rm := NewRoomManager(ctx, nil, DefaultRoomConfig())
+	room, _ := rm.CreateRoom("api", RoomSettings{})
+	room.Inbox <- MoveRequest{X: -3, Y: 700, Color: ColorGreen}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeRoomAPI(rm, w, r)
+	})
+
+	var state ChunkState
+	var etag string
+	deadline := time.Now().Add(2 * time.Second)
+	for len(state.Cells) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("chunk never showed the move")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rooms/api/chunks/-1/1", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		etag = rec.Header().Get("ETag")
+	}
+	if state.Version == 0 || state.Cells[0].X != -3 || state.Cells[0].Y != 700 {
+		t.Fatalf("unexpected chunk state: %+v", state)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/rooms/api/chunks/-1/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rooms/missing/chunks/0/0", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown room, got %d", rec.Code)
+	}
+}
+
+func TestCreateRoomAndMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemoryStore()
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/rooms":
+			ServeRooms(rm, w, r)
+		case "/ws":
+			ServeWS(rm, w, r)
+		default:
+			ServeRoomAPI(rm, w, r)
+		}
+	})
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	for _, body := range []string{
+		`{"id":"bad name"}`,
+		`{"id":"ok","display_name":"` + strings.Repeat("x", MaxDisplayNameLength+1) + `"}`,
+		`{"id":"ok","owner":"tab\there"}`,
+		`{"id":"ok","max_players":0,"rules":{"teams":-1}}`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, code)
+		}
+	}
+	if code := post(`{"id":"arena","display_name":" 周五大战 ","owner":"ana","max_players":4}`); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+
+	// Connecting to a room that was never created is refused
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws?room=arnea", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown room, got %d", rec.Code)
+	}
+
+	get := func(rm *RoomManager) RoomInfo {
+		rec := httptest.NewRecorder()
+		ServeRoomAPI(rm, rec, httptest.NewRequest(http.MethodGet, "/api/rooms/arena", nil))
+		var info RoomInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("get: %d %v", rec.Code, err)
+		}
+		return info
+	}
+	info := get(rm)
+	if info.DisplayName != "周五大战" || info.Owner != "ana" || info.MaxPlayers != 4 || info.CreatedAt.IsZero() {
+		t.Fatalf("unexpected metadata %+v", info)
+	}
+
+	// A saved room opens again after a restart
+	cancel()
+	rm.Wait()
+	restarted := NewRoomManager(context.Background(), store, DefaultRoomConfig())
+	if again := get(restarted); again.DisplayName != info.DisplayName || !again.CreatedAt.Equal(info.CreatedAt) {
+		t.Fatalf("metadata changed across restart: %+v", again)
+	}
+}
+
+func TestCreatedRoomsTakeConfiguredRules(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultRoomConfig()
+	config.Rules.Ko = KoSuperko
+	rm := NewRoomManager(ctx, nil, config)
+
+	room, err := rm.CreateRoom(DefaultRoomID, RoomSettings{})
+	if err != nil || room.Rules.Ko != KoSuperko {
+		t.Fatalf("expected the configured ko rule, got %+v: %v", room.Rules, err)
+	}
+
+	rec := httptest.NewRecorder()
+	ServeRooms(rm, rec, httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(`{"id":"plain"}`)))
+	var created CreatedRoom
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %v", rec.Code, err)
+	}
+	if created.Rules.Ko != KoSuperko {
+		t.Fatalf("a room created without rules should get the configured ones, got %+v", created.Rules)
+	}
+
+	// Rules given explicitly win
+	rec = httptest.NewRecorder()
+	ServeRooms(rm, rec, httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(`{"id":"simple","rules":{"ko":"simple"}}`)))
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Rules.Ko != KoSimple {
+		t.Fatalf("expected simple ko, got %+v: %v", created.Rules, err)
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxPlayers matches the rooms.max_players column default
+const DefaultMaxPlayers = 5
+
+// CloseRoomFull is sent when a player's connection raced another for the
+// last slot; clients can rejoin as spectators
+const CloseRoomFull = 4002
+
+// ErrRoomFull is returned when every player slot of a room is taken
+var ErrRoomFull = errors.New("room_full")
+
+// errRoomRetired is returned for rooms that are being stopped
+var errRoomRetired = errors.New("room is stopping")
+
+// validMaxPlayers checks a room capacity. Seats hold one color each and
+// colors run 0-254, so a room cannot seat more than 255 players; 0 is
+// unlimited.
+func validMaxPlayers(n int) error {
+	if n < 0 || n > int(emptyCell) {
+		return fmt.Errorf("max_players must be between 0 (unlimited) and %d", int(emptyCell))
+	}
+	return nil
+}
+
+// playerSlots returns what takes a player slot: every session holding a
+// seat, connected or within its grace period, and every other session with
+// a player connection. Tabs of one session share a slot; a connection whose
+// session is not known yet takes its own. The caller holds clMu.
+func (r *Room) playerSlots() map[any]struct{} {
+	slots := make(map[any]struct{}, len(r.seated)+len(r.clients))
+	for session := range r.seated {
+		slots[session] = struct{}{}
+	}
+	for c, session := range r.clients {
+		switch {
+		case c.spectator:
+		case session == "":
+			slots[c] = struct{}{}
+		default:
+			slots[session] = struct{}{}
+		}
+	}
+	return slots
+}
+
+// hasSlotFor reports whether a player connection of session fits in the
+// room. A session that holds a seat or is already playing always fits. The
+// caller holds clMu.
+func (r *Room) hasSlotFor(session string) bool {
+	if r.MaxPlayers <= 0 {
+		return true
+	}
+	slots := r.playerSlots()
+	if _, ok := slots[session]; ok && session != "" {
+		return true
+	}
+	return len(slots) < r.MaxPlayers
+}
+
+// full reports whether a player connection of session would exceed the
+// room's capacity
+func (r *Room) full(session string) bool {
+	r.clMu.RLock()
+	defer r.clMu.RUnlock()
+	return !r.hasSlotFor(session)
+}
+
+// spectatorCount counts spectator connections. The caller holds clMu.
+func (r *Room) spectatorCount() int {
+	n := 0
+	for c := range r.clients {
+		if c.spectator {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRoomCapacity(t *testing.T) {
+	rm, srv := adminServer(t, nil)
+	if resp := adminPost(t, srv, "/api/admin/rooms", "secret", `{"id":"duel","max_players":256}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for too many players, got %d", resp.StatusCode)
+	}
+	if resp := adminPost(t, srv, "/api/admin/rooms", "secret", `{"id":"duel","max_players":1}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %d", resp.StatusCode)
+	}
+	joinWithColor(t, srv, "duel", ColorBlack)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=duel"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for a full room, got %v", err)
+	}
+	watcher, _, err := websocket.DefaultDialer.Dial(url+"&fallback=spectator", nil)
+	if err != nil {
+		t.Fatalf("dial with fallback: %v", err)
+	}
+	defer watcher.Close()
+	watcher.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var env Envelope
+	if err := watcher.ReadJSON(&env); err != nil || env.MoveResult == nil || env.MoveResult.Reason != ErrRoomFull.Error() {
+		t.Fatalf("expected room_full, got %+v: %v", env, err)
+	}
+	if err := watcher.ReadJSON(&env); err != nil || env.RoomInfo == nil {
+		t.Fatalf("expected room_info, got %+v: %v", env, err)
+	}
+	if info := env.RoomInfo; info.PlayerCount != 1 || info.SpectatorCount != 1 || info.MaxPlayers != 1 {
+		t.Fatalf("unexpected room info %+v", info)
+	}
+
+	// Spectators never take player slots
+	room, _ := rm.GetRoom("duel")
+	if !room.full("") {
+		t.Fatalf("room should still be full")
+	}
+}
+
+func TestCapacitySurvivesRestart(t *testing.T) {
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	if _, err := rm.CreateRoom("trio", RoomSettings{MaxPlayers: 3}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	cancel()
+	rm.Wait()
+
+	restored, _ := NewRoomManager(context.Background(), store, DefaultRoomConfig()).OpenRoom("trio")
+	if restored.MaxPlayers != 3 {
+		t.Fatalf("expected capacity 3 after restart, got %d", restored.MaxPlayers)
+	}
+}
+
+func TestUnlimitedCapacity(t *testing.T) {
+	config := DefaultRoomConfig()
+	config.MaxPlayers = 0
+	rm := NewRoomManager(context.Background(), nil, config)
+	room, err := rm.CreateRoom("crowd", RoomSettings{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if room.MaxPlayers != 0 {
+		t.Fatalf("expected an unlimited room, got %d", room.MaxPlayers)
+	}
+	for i := 0; i < 300; i++ {
+		room.addClient(&Client{room: room})
+	}
+	if room.full("") {
+		t.Fatalf("an unlimited room should never be full")
+	}
+}
+
+func TestCapacityCountsSessions(t *testing.T) {
+	rm, srv := adminServer(t, nil)
+	if _, err := rm.CreateRoom("solo", RoomSettings{MaxPlayers: 1}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=solo"
+	// dial connects and waits for the session the server sent
+	dial := func(query string) (*websocket.Conn, Session) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err != nil {
+			t.Fatalf("dial %q: %v", query, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var env Envelope
+			if err := conn.ReadJSON(&env); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if env.Type == "session" {
+				return conn, *env.Session
+			}
+		}
+	}
+
+	first, session := dial("")
+	first.WriteJSON(map[string]interface{}{"type": "select_color", "color": int(ColorRed)})
+	var env Envelope
+	if err := first.ReadJSON(&env); err != nil || env.Type != "color_selected" {
+		t.Fatalf("expected color_selected, got %+v: %v", env, err)
+	}
+
+	// A second tab of the same session shares the slot
+	second, _ := dial("&session=" + session.Token)
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for another player, got %v", err)
+	}
+
+	// A player reloading within the grace period keeps the seat and slot
+	first.Close()
+	second.Close()
+	room, _ := rm.GetRoom("solo")
+	deadline := time.Now().Add(2 * time.Second)
+	for _, empty := room.idleSince(); !empty; _, empty = room.idleSince() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tabs did not leave, got %+v", room.Info())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info := room.Info(); info.PlayerCount != 1 {
+		t.Fatalf("the held seat should count as a player, got %+v", info)
+	}
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 while the seat is held, got %v", err)
+	}
+	if _, resumed := dial("&session=" + session.Token); resumed.Color == nil || *resumed.Color != ColorRed {
+		t.Fatalf("expected the red seat back, got %+v", resumed)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// MaxChunksPerRequest caps how many chunks one get_chunks request may ask for
+const MaxChunksPerRequest = 64
+
+// ChunkState is a single chunk of the board together with its version
+type ChunkState struct {
+	X         int32  `json:"x"`
+	Y         int32  `json:"y"`
+	Version   uint64 `json:"version"`
+	Cells     []Cell `json:"cells"`
+	ServerSeq uint64 `json:"server_seq"`
+}
+
+// ChunksRequest asks the room goroutine for chunks. WebSocket clients get
+// one chunk_state envelope per chunk; HTTP handlers set Reply instead.
+type ChunksRequest struct {
+	Player *Client
+	IDs    []ChunkID
+	Reply  chan []ChunkState
+}
+
+// chunkState copies one chunk with its cells sorted by position. Missing
+// chunks are returned empty with version 0.
+func (r *Room) chunkState(id ChunkID) ChunkState {
+	state := ChunkState{X: id.X, Y: id.Y, ServerSeq: r.Seq}
+	ch, ok := r.Chunks[id]
+	if !ok {
+		return state
+	}
+	state.Version = ch.Version
+	state.Cells = chunkCells(map[ChunkID]*Chunk{id: ch})
+	sort.Slice(state.Cells, func(i, j int) bool {
+		a, b := state.Cells[i], state.Cells[j]
+		return a.X < b.X || (a.X == b.X && a.Y < b.Y)
+	})
+	return state
+}
+
+func (r *Room) serveChunks(req ChunksRequest) {
+	states := make([]ChunkState, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		states = append(states, r.chunkState(id))
+	}
+	if req.Reply != nil {
+		req.Reply <- states
+		return
+	}
+	if req.Player == nil {
+		return
+	}
+	// Separate messages keep each frame small for the browser to parse
+	for i := range states {
+		req.Player.sendEnvelope(Envelope{Type: "chunk_state", ChunkState: &states[i]})
+	}
+}
+
+// ETag hashes the chunk contents. Versions restart with the server, so the
+// hash is what lets HTTP caches revalidate safely across restarts.
+func (s ChunkState) ETag() string {
+	h := fnv.New64a()
+	var buf [17]byte
+	for _, c := range s.Cells {
+		binary.LittleEndian.PutUint64(buf[0:], uint64(c.X))
+		binary.LittleEndian.PutUint64(buf[8:], uint64(c.Y))
+		buf[16] = byte(c.Color)
+		h.Write(buf[:])
+	}
+	return fmt.Sprintf(`"%d.%d.%x"`, s.X, s.Y, h.Sum64())
+}
@@ -6,13 +6,23 @@ import (
 )
 
 const (
-	ChunkSize     = 512
-	chunkBits     = 9
-	chunkSizeMask = ChunkSize - 1
+	ChunkSize      = 512
+	chunkBits      = 9
+	chunkSizeMask  = ChunkSize - 1
+	chunkCellCount = ChunkSize * ChunkSize
 )
 
 var (
-	ErrOutOfBounds = errors.New("coordinate out of chunk range")
+	ErrOutOfBounds  = errors.New("coordinate out of chunk range")
+	ErrInvalidColor = errors.New("invalid_color")
+)
+
+// Fill levels at which a chunk switches between the sparse map and the
+// dense array. The gap between them stops a chunk hovering near one level
+// from converting back and forth.
+var (
+	denseFill  = chunkCellCount / 8
+	sparseFill = chunkCellCount / 32
 )
 
 type Color uint8
@@ -28,16 +38,28 @@ const (
 	ColorOrange Color = 7
 	ColorCyan   Color = 8
 	ColorPink   Color = 9
-	// Color type supports 0-255, allowing for many more colors if needed
+	// Color type supports 0-254, allowing for many more colors if needed
+
+	// emptyCell marks free points in dense chunks and is not a valid color
+	emptyCell Color = 255
 )
 
+// Chunk holds the stones of a ChunkSize x ChunkSize area. Stones live in a
+// sparse map until the chunk fills up, then in a dense array indexed by
+// localIndex; callers go through get, set, remove and each either way.
 type Chunk struct {
 	X, Y  int32
-	Cells map[uint32]Color
+	cells map[uint32]Color
+	dense []Color
+	count int
+	// Version changes whenever a cell of the chunk changes. It is only
+	// meaningful within one server run.
+	Version uint64
 }
 
 type ChunkID struct {
-	X, Y int32
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
 }
 
 type Cell struct {
@@ -59,6 +81,112 @@ func localIndex(x, y int64) uint32 {
 	return (uint32(uint64(x)&chunkSizeMask) << chunkBits) | uint32(uint64(y)&chunkSizeMask)
 }
 
+func newChunk(x, y int32) *Chunk {
+	return &Chunk{X: x, Y: y, cells: make(map[uint32]Color)}
+}
+
+// newChunkFromCells builds a chunk in whichever representation suits its fill
+func newChunkFromCells(x, y int32, cells map[uint32]Color) *Chunk {
+	ch := &Chunk{X: x, Y: y, cells: cells}
+	if ch.cells == nil {
+		ch.cells = make(map[uint32]Color)
+	}
+	ch.count = len(ch.cells)
+	if ch.count >= denseFill {
+		ch.toDense()
+	}
+	return ch
+}
+
+func (c *Chunk) len() int {
+	return c.count
+}
+
+func (c *Chunk) get(idx uint32) (Color, bool) {
+	if c.dense != nil {
+		color := c.dense[idx]
+		return color, color != emptyCell
+	}
+	color, ok := c.cells[idx]
+	return color, ok
+}
+
+func (c *Chunk) set(idx uint32, color Color) {
+	if c.dense != nil {
+		if c.dense[idx] == emptyCell {
+			c.count++
+		}
+		c.dense[idx] = color
+		return
+	}
+	if c.cells == nil {
+		c.cells = make(map[uint32]Color)
+	}
+	if _, ok := c.cells[idx]; !ok {
+		c.count++
+	}
+	c.cells[idx] = color
+	if c.count >= denseFill {
+		c.toDense()
+	}
+}
+
+func (c *Chunk) remove(idx uint32) {
+	if c.dense != nil {
+		if c.dense[idx] != emptyCell {
+			c.count--
+			c.dense[idx] = emptyCell
+		}
+		if c.count <= sparseFill {
+			c.toSparse()
+		}
+		return
+	}
+	if _, ok := c.cells[idx]; ok {
+		c.count--
+		delete(c.cells, idx)
+	}
+}
+
+// each calls fn for every stone, in index order for dense chunks and in no
+// particular order for sparse ones.
+func (c *Chunk) each(fn func(idx uint32, color Color)) {
+	if c.dense != nil {
+		for idx, color := range c.dense {
+			if color != emptyCell {
+				fn(uint32(idx), color)
+			}
+		}
+		return
+	}
+	for idx, color := range c.cells {
+		fn(idx, color)
+	}
+}
+
+// cellMap copies the stones into a map, the layout of the JSON stores
+func (c *Chunk) cellMap() map[uint32]Color {
+	cells := make(map[uint32]Color, c.count)
+	c.each(func(idx uint32, color Color) { cells[idx] = color })
+	return cells
+}
+
+func (c *Chunk) toDense() {
+	c.dense = make([]Color, chunkCellCount)
+	for i := range c.dense {
+		c.dense[i] = emptyCell
+	}
+	for idx, color := range c.cells {
+		c.dense[idx] = color
+	}
+	c.cells = nil
+}
+
+func (c *Chunk) toSparse() {
+	c.cells = c.cellMap()
+	c.dense = nil
+}
+
 func (r *Room) getChunk(id ChunkID, create bool) *Chunk {
 	ch, ok := r.Chunks[id]
 	if ok {
@@ -67,22 +195,42 @@ func (r *Room) getChunk(id ChunkID, create bool) *Chunk {
 	if !create {
 		return nil
 	}
-	nc := &Chunk{
-		X:     id.X,
-		Y:     id.Y,
-		Cells: make(map[uint32]Color),
-	}
+	nc := newChunk(id.X, id.Y)
 	r.Chunks[id] = nc
 	return nc
 }
 
+func (c *Chunk) clone() *Chunk {
+	cp := &Chunk{X: c.X, Y: c.Y, count: c.count, Version: c.Version}
+	if c.dense != nil {
+		cp.dense = append([]Color(nil), c.dense...)
+		return cp
+	}
+	cp.cells = make(map[uint32]Color, len(c.cells))
+	for idx, color := range c.cells {
+		cp.cells[idx] = color
+	}
+	return cp
+}
+
 func (r *Room) setCell(x, y int64, color Color) error {
+	if color == emptyCell {
+		return ErrInvalidColor
+	}
 	id, err := chunkIDFor(x, y)
 	if err != nil {
 		return err
 	}
 	ch := r.getChunk(id, true)
-	ch.Cells[localIndex(x, y)] = color
+	idx := localIndex(x, y)
+	if old, ok := ch.get(idx); ok {
+		r.hash ^= stoneHash(x, y, old)
+	}
+	ch.set(idx, color)
+	r.hash ^= stoneHash(x, y, color)
+	r.chunkClock++
+	ch.Version = r.chunkClock
+	r.markDirty(id)
 	return nil
 }
 
@@ -96,8 +244,16 @@ func (r *Room) removeCell(x, y int64) {
 		return
 	}
 	idx := localIndex(x, y)
-	delete(ch.Cells, idx)
-	if len(ch.Cells) == 0 {
+	old, ok := ch.get(idx)
+	if !ok {
+		return
+	}
+	r.hash ^= stoneHash(x, y, old)
+	ch.remove(idx)
+	r.chunkClock++
+	ch.Version = r.chunkClock
+	r.markDirty(id)
+	if ch.len() == 0 {
 		delete(r.Chunks, id)
 	}
 }
@@ -111,8 +267,7 @@ func (r *Room) getCell(x, y int64) (Color, bool) {
 	if ch == nil {
 		return 0, false
 	}
-	color, ok := ch.Cells[localIndex(x, y)]
-	return color, ok
+	return ch.get(localIndex(x, y))
 }
 
 func (r *Room) hasStone(x, y int64) bool {
@@ -121,17 +276,22 @@ func (r *Room) hasStone(x, y int64) bool {
 }
 
 func (r *Room) getAllCells() []Cell {
+	return chunkCells(r.Chunks)
+}
+
+// chunkCells lists the cells of every chunk with absolute coordinates
+func chunkCells(chunks map[ChunkID]*Chunk) []Cell {
 	var cells []Cell
-	for chunkID, chunk := range r.Chunks {
+	for chunkID, chunk := range chunks {
 		baseX := int64(chunkID.X) << chunkBits
 		baseY := int64(chunkID.Y) << chunkBits
-		for idx, color := range chunk.Cells {
+		chunk.each(func(idx uint32, color Color) {
 			localX := int64((idx >> chunkBits) & chunkSizeMask)
 			localY := int64(idx & chunkSizeMask)
 			x := baseX + localX
 			y := baseY + localY
 			cells = append(cells, Cell{X: x, Y: y, Color: color})
-		}
+		})
 	}
 	return cells
 }
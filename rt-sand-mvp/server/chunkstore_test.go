@@ -0,0 +1,113 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkSwitchesRepresentation(t *testing.T) {
+	room := NewRoom()
+	id := ChunkID{X: 0, Y: 0}
+	for i := 0; i < denseFill; i++ {
+		room.setCell(int64(i/ChunkSize), int64(i%ChunkSize), Color(i%3))
+	}
+	ch := room.Chunks[id]
+	if ch.dense == nil || ch.len() != denseFill {
+		t.Fatalf("chunk with %d stones should be dense", ch.len())
+	}
+	if color, ok := room.getCell(1, 5); !ok || color != Color((ChunkSize+5)%3) {
+		t.Fatalf("unexpected cell after switching to dense: %d, %v", color, ok)
+	}
+
+	// Removing down to sparseFill switches back, keeping the same stones
+	for i := denseFill - 1; i >= sparseFill; i-- {
+		room.removeCell(int64(i/ChunkSize), int64(i%ChunkSize))
+	}
+	if ch.dense != nil || ch.len() != sparseFill || len(ch.cells) != sparseFill {
+		t.Fatalf("chunk with %d stones should be sparse", ch.len())
+	}
+	if color, ok := room.getCell(1, 5); !ok || color != Color((ChunkSize+5)%3) {
+		t.Fatalf("unexpected cell after switching to sparse: %d, %v", color, ok)
+	}
+	if room.hasStone(int64(sparseFill/ChunkSize), int64(sparseFill%ChunkSize)) {
+		t.Fatalf("removed stone still present")
+	}
+
+	if err := room.setCell(0, 0, emptyCell); err != ErrInvalidColor {
+		t.Fatalf("expected ErrInvalidColor for the empty sentinel, got %v", err)
+	}
+}
+
+func TestDenseChunkPlaysLikeSparse(t *testing.T) {
+	play := func(dense bool) []MoveResult {
+		defer withFill(fillFor(dense))()
+		room := NewRoom()
+		var results []MoveResult
+		for i := 0; i < 400; i++ {
+			x, y := int64(i*7%23)+ChunkSize-10, int64(i*11%19)-8
+			results = append(results, room.ProcessMove(MoveRequest{X: x, Y: y, Color: Color(i % 2)}))
+		}
+		return results
+	}
+	sparse, dense := play(false), play(true)
+	for i := range sparse {
+		if !reflect.DeepEqual(sparse[i], dense[i]) {
+			t.Fatalf("move %d differs: sparse %+v, dense %+v", i, sparse[i], dense[i])
+		}
+	}
+}
+
+// fillFor returns thresholds that force every chunk sparse or dense
+func fillFor(dense bool) (int, int) {
+	if dense {
+		return 1, 0
+	}
+	return chunkCellCount + 1, chunkCellCount
+}
+
+func withFill(dense, sparse int) func() {
+	oldDense, oldSparse := denseFill, sparseFill
+	denseFill, sparseFill = dense, sparse
+	return func() { denseFill, sparseFill = oldDense, oldSparse }
+}
+
+// battleRoom fills a 256x256 area straddling four chunks with large
+// interlocking groups, leaving a lattice of empty points to play on.
+func battleRoom() (*Room, []coord) {
+	room := NewRoom()
+	var holes []coord
+	for x := int64(-128); x < 128; x++ {
+		for y := int64(-128); y < 128; y++ {
+			if x%3 == 0 && y%3 == 0 {
+				holes = append(holes, coord{X: x, Y: y})
+				continue
+			}
+			room.setCell(x, y, Color((x/16+y/16)&1))
+		}
+	}
+	return room, holes
+}
+
+func BenchmarkProcessMoveBattle(b *testing.B) {
+	for _, dense := range []bool{false, true} {
+		name := "sparse"
+		if dense {
+			name = "dense"
+		}
+		b.Run(name, func(b *testing.B) {
+			defer withFill(fillFor(dense))()
+			room, holes := battleRoom()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h := holes[i%len(holes)]
+				req := MoveRequest{X: h.X, Y: h.Y, Color: Color(i & 1)}
+				res := room.ProcessMove(req)
+				// Put captured stones back so every iteration plays on the
+				// same board
+				b.StopTimer()
+				room.undoMove(req, res.Removed)
+				b.StartTimer()
+			}
+		})
+	}
+}
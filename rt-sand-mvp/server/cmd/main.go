@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -17,8 +17,19 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	// Open the storage backend selected by STORE_BACKEND
+	store, err := server.OpenStore(server.GetStoreConfig())
+	if err != nil {
+		log.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
 	// Create room manager to handle multiple rooms
-	roomManager := server.NewRoomManager(ctx)
+	roomManager := server.NewRoomManager(ctx, store, server.GetRoomConfig())
+	// Connections no longer create rooms, so make sure the default one exists
+	if _, err := roomManager.CreateRoom(server.DefaultRoomID, server.RoomSettings{}); err != nil && !errors.Is(err, server.ErrRoomExists) {
+		log.Printf("create default room: %v", err)
+	}
 
 	mux := http.NewServeMux()
 
@@ -28,19 +39,29 @@ func main() {
 	}
 	fs := http.FileServer(http.Dir(staticDir))
 	mux.Handle("/", fs)
-	
+
 	// WebSocket handler with room support
 	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		server.ServeWS(roomManager, w, r)
 	})
-	
-	// API endpoint to list rooms
+
+	// API endpoint to list and create rooms
 	mux.HandleFunc("/api/rooms", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		rooms := roomManager.GetRoomInfoList()
-		if err := json.NewEncoder(w).Encode(rooms); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		server.ServeRooms(roomManager, w, r)
+	})
+
+	// Admin API, disabled unless ADMIN_TOKEN is set
+	adminToken := server.GetAdminToken()
+	mux.HandleFunc("/api/admin/rooms", func(w http.ResponseWriter, r *http.Request) {
+		server.ServeAdmin(roomManager, adminToken, w, r)
+	})
+	mux.HandleFunc("/api/admin/rooms/", func(w http.ResponseWriter, r *http.Request) {
+		server.ServeAdmin(roomManager, adminToken, w, r)
+	})
+
+	// Per-room API: chunk paging
+	mux.HandleFunc("/api/rooms/", func(w http.ResponseWriter, r *http.Request) {
+		server.ServeRoomAPI(roomManager, w, r)
 	})
 
 	addr := ":8080"
@@ -60,4 +81,7 @@ func main() {
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("server error: %v", err)
 	}
+
+	// Let rooms write their final state before the database closes
+	roomManager.Wait()
 }
@@ -0,0 +1,227 @@
+package server
+
+//go:generate protoc -I ../protocol --go_out=protocol --go_opt=paths=source_relative move.proto
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Anthony-pi-Franklin/InfiniteGo/rt-sand-mvp/server/protocol"
+)
+
+// WebSocket subprotocols. Clients that offer neither get JSON text frames.
+const (
+	SubprotocolProto = "infinitego.proto"
+	SubprotocolJSON  = "infinitego.json"
+)
+
+// clientMessage is a decoded client request in either encoding. Numbers stay
+// as json.Number so coordinates are validated where they are used.
+type clientMessage struct {
+	Type   string      `json:"type"`
+	X      json.Number `json:"x"`
+	Y      json.Number `json:"y"`
+	Color  int         `json:"color"`
+	MinX   json.Number `json:"min_x"`
+	MinY   json.Number `json:"min_y"`
+	MaxX   json.Number `json:"max_x"`
+	MaxY   json.Number `json:"max_y"`
+	Chunks []ChunkID   `json:"chunks"`
+	Speed  float64     `json:"speed"`
+}
+
+// decodeClientMessage parses a frame according to its WebSocket message type
+func decodeClientMessage(messageType int, data []byte) (clientMessage, error) {
+	var msg clientMessage
+	if messageType != websocket.BinaryMessage {
+		err := json.Unmarshal(data, &msg)
+		return msg, err
+	}
+	var pb protocol.ClientMessage
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return msg, err
+	}
+	msg.Type = pb.Type
+	msg.X = int64Number(pb.X)
+	msg.Y = int64Number(pb.Y)
+	msg.Color = int(pb.Color)
+	msg.MinX = optionalNumber(pb.MinX)
+	msg.MinY = optionalNumber(pb.MinY)
+	msg.MaxX = optionalNumber(pb.MaxX)
+	msg.MaxY = optionalNumber(pb.MaxY)
+	for _, id := range pb.Chunks {
+		msg.Chunks = append(msg.Chunks, ChunkID{X: id.X, Y: id.Y})
+	}
+	msg.Speed = pb.Speed
+	return msg, nil
+}
+
+func int64Number(v int64) json.Number {
+	return json.Number(strconv.FormatInt(v, 10))
+}
+
+// optionalNumber leaves unset fields empty, like a missing JSON key
+func optionalNumber(v *int64) json.Number {
+	if v == nil {
+		return ""
+	}
+	return int64Number(*v)
+}
+
+// encodeEnvelope serializes an envelope as protobuf or JSON
+func encodeEnvelope(env Envelope, binary bool) ([]byte, error) {
+	if env.BoardState != nil && len(env.BoardState.Cells) > 0 {
+		packed := env.BoardState.packed()
+		env.BoardState = &packed
+	}
+	if binary {
+		return proto.Marshal(env.toProto())
+	}
+	return json.Marshal(env)
+}
+
+func (env Envelope) toProto() *protocol.Envelope {
+	pb := &protocol.Envelope{Type: env.Type}
+	if r := env.MoveResult; r != nil {
+		pb.MoveResult = &protocol.MoveResult{
+			Accepted:     r.Accepted,
+			Reason:       r.Reason,
+			Removed:      cellsToProto(r.Removed),
+			ServerSeq:    r.ServerSeq,
+			RetryAfterMs: r.RetryAfter,
+			FreeColors:   colorsToProto(r.FreeColors),
+		}
+		if r.Added != nil {
+			pb.MoveResult.Added = cellToProto(*r.Added)
+		}
+	}
+	if d := env.DeltaUpdate; d != nil {
+		pb.DeltaUpdate = &protocol.DeltaUpdate{
+			Added:     cellsToProto(d.Added),
+			Removed:   cellsToProto(d.Removed),
+			ServerSeq: d.ServerSeq,
+		}
+	}
+	if s := env.BoardState; s != nil {
+		pb.BoardState = &protocol.BoardState{
+			Cells:     cellsToProto(s.Cells),
+			ServerSeq: s.ServerSeq,
+		}
+		for _, id := range s.Chunks {
+			pb.BoardState.Chunks = append(pb.BoardState.Chunks, &protocol.ChunkID{X: id.X, Y: id.Y})
+		}
+		for _, p := range s.Packed {
+			pb.BoardState.Packed = append(pb.BoardState.Packed, &protocol.PackedChunk{X: p.X, Y: p.Y, Data: p.Data})
+		}
+	}
+	if info := env.RoomInfo; info != nil {
+		pb.RoomInfo = &protocol.RoomInfo{
+			Id:             info.ID,
+			PlayerCount:    int32(info.PlayerCount),
+			SpectatorCount: int32(info.SpectatorCount),
+			MaxPlayers:     int32(info.MaxPlayers),
+			DisplayName:    info.DisplayName,
+			Owner:          info.Owner,
+			TakenColors:    colorsToProto(info.TakenColors),
+			Rules: &protocol.RuleSet{
+				Ko:               info.Rules.Ko.String(),
+				ForbidSuicide:    info.Rules.ForbidSuicide,
+				SelfCaptureFirst: info.Rules.SelfCaptureFirst,
+				Teams:            int32(info.Rules.Teams),
+				TickMs:           int32(info.Rules.TickMS),
+			},
+		}
+		for _, a := range info.Rules.Alliances {
+			colors := make([]int32, len(a))
+			for i, c := range a {
+				colors[i] = int32(c)
+			}
+			pb.RoomInfo.Rules.Alliances = append(pb.RoomInfo.Rules.Alliances, &protocol.Alliance{Colors: colors})
+		}
+	}
+	if s := env.Score; s != nil {
+		pb.Score = &protocol.Score{
+			Region: &protocol.Rect{
+				MinX: s.Region.MinX,
+				MinY: s.Region.MinY,
+				MaxX: s.Region.MaxX,
+				MaxY: s.Region.MaxY,
+			},
+			Neutral:   int32(s.Neutral),
+			ServerSeq: s.ServerSeq,
+		}
+		for _, cs := range s.Colors {
+			pb.Score.Colors = append(pb.Score.Colors, &protocol.ColorScore{
+				Color:     int32(cs.Color),
+				Stones:    int32(cs.Stones),
+				Territory: int32(cs.Territory),
+				Score:     int32(cs.Score),
+			})
+		}
+	}
+	if b := env.Leaderboard; b != nil {
+		pb.Leaderboard = &protocol.Leaderboard{ServerSeq: b.ServerSeq}
+		for _, s := range b.Colors {
+			pb.Leaderboard.Colors = append(pb.Leaderboard.Colors, colorStatsToProto(s))
+		}
+	}
+	if s := env.Session; s != nil {
+		pb.Session = &protocol.Session{Token: s.Token}
+		if s.Color != nil {
+			color := int32(*s.Color)
+			pb.Session.Color = &color
+		}
+		if st := s.Stats; st != nil {
+			pb.Session.Stats = colorStatsToProto(*st)
+		}
+	}
+	if s := env.ChunkState; s != nil {
+		pb.ChunkState = &protocol.ChunkState{
+			X:         s.X,
+			Y:         s.Y,
+			Version:   s.Version,
+			Cells:     cellsToProto(s.Cells),
+			ServerSeq: s.ServerSeq,
+		}
+	}
+	return pb
+}
+
+func colorsToProto(colors ColorList) []int32 {
+	if len(colors) == 0 {
+		return nil
+	}
+	out := make([]int32, len(colors))
+	for i, c := range colors {
+		out[i] = int32(c)
+	}
+	return out
+}
+
+func colorStatsToProto(s ColorStats) *protocol.ColorStats {
+	return &protocol.ColorStats{
+		Color:    int32(s.Color),
+		Stones:   int32(s.Stones),
+		Captured: int32(s.Captured),
+		Lost:     int32(s.Lost),
+		Moves:    int32(s.Moves),
+	}
+}
+
+func cellToProto(c Cell) *protocol.Cell {
+	return &protocol.Cell{X: c.X, Y: c.Y, Color: int32(c.Color)}
+}
+
+func cellsToProto(cells []Cell) []*protocol.Cell {
+	if len(cells) == 0 {
+		return nil
+	}
+	out := make([]*protocol.Cell, len(cells))
+	for i, c := range cells {
+		out[i] = cellToProto(c)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Anthony-pi-Franklin/InfiniteGo/rt-sand-mvp/server/protocol"
+)
+
+func TestEnvelopeProtoMatchesJSON(t *testing.T) {
+	env := Envelope{Type: "board_state", BoardState: &BoardState{
+		Cells:     []Cell{{X: -1 << 40, Y: 7, Color: ColorRed}},
+		Chunks:    []ChunkID{{X: -2, Y: 3}},
+		ServerSeq: 42,
+	}}
+	payload, err := encodeEnvelope(env, true)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var pb protocol.Envelope
+	if err := proto.Unmarshal(payload, &pb); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	state := pb.GetBoardState()
+	if pb.Type != "board_state" || state.ServerSeq != 42 || len(state.Packed) != 1 {
+		t.Fatalf("unexpected envelope: %v", &pb)
+	}
+	p := state.Packed[0]
+	ch, err := PackedChunk{X: p.X, Y: p.Y, Data: p.Data}.unpack()
+	if err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	if cells := chunkCells(map[ChunkID]*Chunk{{X: ch.X, Y: ch.Y}: ch}); len(cells) != 1 || cells[0] != (Cell{X: -1 << 40, Y: 7, Color: ColorRed}) {
+		t.Fatalf("unexpected cells: %v", cells)
+	}
+	if id := state.Chunks[0]; id.X != -2 || id.Y != 3 {
+		t.Fatalf("unexpected chunk: %v", id)
+	}
+}
+
+func TestDecodeClientMessageProto(t *testing.T) {
+	data, err := proto.Marshal(&protocol.ClientMessage{Type: "get_chunks", Chunks: []*protocol.ChunkID{{X: 1, Y: -1}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	msg, err := decodeClientMessage(websocket.BinaryMessage, data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.Type != "get_chunks" || len(msg.Chunks) != 1 || msg.Chunks[0] != (ChunkID{X: 1, Y: -1}) {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestWebSocketSubprotocolNegotiation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rm := NewRoomManager(ctx, nil, DefaultRoomConfig())
+	rm.CreateRoom("codec", RoomSettings{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWS(rm, w, r)
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=codec"
+
+	// Binary clients send and receive protobuf frames
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolProto}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != SubprotocolProto {
+		t.Fatalf("expected %s, got %q", SubprotocolProto, conn.Subprotocol())
+	}
+	readProto := func() *protocol.Envelope {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		var env protocol.Envelope
+		if messageType != websocket.BinaryMessage || proto.Unmarshal(data, &env) != nil {
+			t.Fatalf("expected a protobuf frame, got type %d: %q", messageType, data)
+		}
+		return &env
+	}
+	if env := readProto(); env.Type != "room_info" || env.GetRoomInfo().GetId() != "codec" {
+		t.Fatalf("expected room_info first, got %v", env)
+	}
+	if env := readProto(); env.Type != "session" || len(env.GetSession().GetToken()) != 4*sessionTokenBytes {
+		t.Fatalf("expected a session token, got %v", env)
+	}
+	req, _ := proto.Marshal(&protocol.ClientMessage{Type: "select_color", Color: int32(ColorBlue)})
+	if err := conn.WriteMessage(websocket.BinaryMessage, req); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if env := readProto(); env.Type != "color_selected" || !env.GetMoveResult().GetAccepted() {
+		t.Fatalf("unexpected reply: %v", env)
+	}
+
+	// Clients without a subprotocol keep the JSON encoding
+	plain, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer plain.Close()
+	if err := plain.WriteJSON(map[string]string{"type": "get_state"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	plain.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []string{"room_info", "session"} {
+		var env Envelope
+		if err := plain.ReadJSON(&env); err != nil || env.Type != want {
+			t.Fatalf("expected %s, got %+v: %v", want, env, err)
+		}
+	}
+	messageType, data, err := plain.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var decoded Envelope
+	if messageType != websocket.TextMessage || json.Unmarshal(data, &decoded) != nil || decoded.Type != "board_state" {
+		t.Fatalf("expected a JSON board_state, got type %d: %q", messageType, data)
+	}
+}
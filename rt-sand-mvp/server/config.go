@@ -0,0 +1,117 @@
+package server
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// RoomConfig holds the tunables shared by every room of a RoomManager
+type RoomConfig struct {
+	FlushInterval       time.Duration   // how often dirty chunks are written
+	SnapshotEvery       int             // moves between snapshots, 0 disables
+	SnapshotInterval    time.Duration   // time between snapshots, 0 disables
+	Retention           RetentionPolicy // which old snapshots to keep
+	Rules               RuleSet         // rules for rooms created without any
+	LeaderboardInterval time.Duration   // throttles leaderboard pushes, 0 disables
+	ClientLimit         RateLimit       // moves per connection
+	ColorLimit          RateLimit       // moves per color, across its connections
+	SeatGrace           time.Duration   // how long a disconnected player keeps their color
+	IdleTimeout         time.Duration   // empty rooms are hibernated after this, 0 disables
+	MaxLiveRooms        int             // rooms held in memory at once, 0 is unlimited
+	MaxPlayers          int             // players per room unless set at creation
+	SessionSecret       string          // signs session tokens, random per process when empty
+}
+
+// DefaultRoomConfig returns the settings used when nothing is configured
+func DefaultRoomConfig() RoomConfig {
+	return RoomConfig{
+		FlushInterval:       DefaultFlushInterval,
+		SnapshotEvery:       1000,
+		SnapshotInterval:    5 * time.Minute,
+		Retention:           DefaultRetentionPolicy(),
+		LeaderboardInterval: DefaultLeaderboardInterval,
+		ClientLimit:         DefaultClientLimit,
+		ColorLimit:          DefaultColorLimit,
+		SeatGrace:           DefaultSeatGrace,
+		IdleTimeout:         DefaultIdleTimeout,
+		MaxLiveRooms:        DefaultMaxLiveRooms,
+		MaxPlayers:          DefaultMaxPlayers,
+	}
+}
+
+// GetRoomConfig reads room settings from environment variables
+func GetRoomConfig() RoomConfig {
+	cfg := DefaultRoomConfig()
+	if d := getEnvDuration("FLUSH_INTERVAL", cfg.FlushInterval); d > 0 {
+		cfg.FlushInterval = d
+	} else {
+		log.Printf("invalid FLUSH_INTERVAL %s, using %s", d, cfg.FlushInterval)
+	}
+	cfg.SnapshotEvery = getEnvInt("SNAPSHOT_EVERY_MOVES", cfg.SnapshotEvery)
+	cfg.SnapshotInterval = getEnvDuration("SNAPSHOT_INTERVAL", cfg.SnapshotInterval)
+	cfg.Retention.KeepAll = getEnvDuration("SNAPSHOT_KEEP_ALL", cfg.Retention.KeepAll)
+	cfg.Retention.KeepHourly = getEnvDuration("SNAPSHOT_KEEP_HOURLY", cfg.Retention.KeepHourly)
+	cfg.LeaderboardInterval = getEnvDuration("LEADERBOARD_INTERVAL", cfg.LeaderboardInterval)
+	cfg.ClientLimit.Rate = getEnvFloat("MOVE_RATE", cfg.ClientLimit.Rate)
+	cfg.ClientLimit.Burst = getEnvInt("MOVE_BURST", cfg.ClientLimit.Burst)
+	cfg.ColorLimit.Rate = getEnvFloat("COLOR_MOVE_RATE", cfg.ColorLimit.Rate)
+	cfg.ColorLimit.Burst = getEnvInt("COLOR_MOVE_BURST", cfg.ColorLimit.Burst)
+	cfg.SeatGrace = getEnvDuration("SEAT_GRACE", cfg.SeatGrace)
+	cfg.IdleTimeout = getEnvDuration("ROOM_IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.MaxLiveRooms = getEnvInt("MAX_LIVE_ROOMS", cfg.MaxLiveRooms)
+	if n := getEnvInt("MAX_PLAYERS", cfg.MaxPlayers); validMaxPlayers(n) == nil {
+		cfg.MaxPlayers = n
+	} else {
+		log.Printf("invalid MAX_PLAYERS %d, using %d", n, cfg.MaxPlayers)
+	}
+	cfg.SessionSecret = getEnv("SESSION_SECRET", cfg.SessionSecret)
+	if ko, err := ParseKoRule(getEnv("KO_RULE", "")); err != nil {
+		log.Printf("%v, using %s", err, cfg.Rules.Ko)
+	} else {
+		cfg.Rules.Ko = ko
+	}
+	return cfg
+}
+
+// getEnvInt reads an integer environment variable with a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvFloat reads a floating point environment variable with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %g", key, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvDuration reads a duration environment variable such as "30s"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -7,13 +7,17 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 -- Rooms table: stores metadata about game rooms
 CREATE TABLE IF NOT EXISTS rooms (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    name VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL, -- room ID used in URLs
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    owner VARCHAR(255) NOT NULL DEFAULT '',
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     is_active BOOLEAN NOT NULL DEFAULT true,
     max_players INTEGER DEFAULT 5,
     current_players INTEGER DEFAULT 0,
-    server_seq BIGINT NOT NULL DEFAULT 0
+    server_seq BIGINT NOT NULL DEFAULT 0,
+    rules JSONB, -- RuleSet the room was created with
+    access JSONB -- password hash and invite key of private rooms
 );
 
 -- Game states table: stores snapshot of entire game state for recovery
@@ -32,17 +36,19 @@ CREATE TABLE IF NOT EXISTS chunks (
     room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
     chunk_x INTEGER NOT NULL,
     chunk_y INTEGER NOT NULL,
-    cells JSONB NOT NULL DEFAULT '{}',
+    cells JSONB NOT NULL DEFAULT '{}', -- legacy JSON cells, superseded by packed
+    packed BYTEA,                      -- run-length cells, see server/packed.go
     stone_count INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     UNIQUE(room_id, chunk_x, chunk_y)
 );
 
--- Moves table: optional, for replay and analytics
+-- Moves table: append-only journal used for crash recovery and replay
 CREATE TABLE IF NOT EXISTS moves (
     id BIGSERIAL PRIMARY KEY,
     room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL DEFAULT 'move',
     player_id VARCHAR(255),
     x BIGINT NOT NULL,
     y BIGINT NOT NULL,
@@ -59,6 +65,9 @@ CREATE TABLE IF NOT EXISTS players (
     session_id VARCHAR(255) NOT NULL,
     color SMALLINT,
     stone_count INTEGER NOT NULL DEFAULT 0,
+    captured INTEGER NOT NULL DEFAULT 0, -- enemy stones captured
+    lost INTEGER NOT NULL DEFAULT 0,     -- own stones captured
+    moves INTEGER NOT NULL DEFAULT 0,
     connected_at TIMESTAMP NOT NULL DEFAULT NOW(),
     last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
     is_connected BOOLEAN NOT NULL DEFAULT true
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// roomNamespace derives stable database UUIDs from room IDs
+var roomNamespace = uuid.MustParse("7c1e4f0a-3b52-4d6e-9a0f-2f1d8c6b5e21")
+
+// GormStore is a Store backed by the PostgreSQL tables in models.go
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a store on top of an open gorm connection
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func roomUUID(roomID string) uuid.UUID {
+	return uuid.NewSHA1(roomNamespace, []byte(roomID))
+}
+
+func (s *GormStore) LoadRoom(roomID string) (*RoomRecord, error) {
+	var row DBRoom
+	err := s.db.Where("id = ?", roomUUID(roomID)).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load room %q: %w", roomID, err)
+	}
+	return decodeRoomRow(row)
+}
+
+func decodeRoomRow(row DBRoom) (*RoomRecord, error) {
+	rec := &RoomRecord{
+		ID:          row.Name,
+		ServerSeq:   row.ServerSeq,
+		MaxPlayers:  row.MaxPlayers,
+		DisplayName: row.DisplayName,
+		Owner:       row.Owner,
+		CreatedAt:   row.CreatedAt,
+	}
+	if len(row.Rules) > 0 {
+		rec.Rules = new(RuleSet)
+		if err := json.Unmarshal(row.Rules, rec.Rules); err != nil {
+			return nil, fmt.Errorf("decode rules of room %q: %w", row.Name, err)
+		}
+	}
+	if len(row.Access) > 0 {
+		rec.Access = new(RoomAccess)
+		if err := json.Unmarshal(row.Access, rec.Access); err != nil {
+			return nil, fmt.Errorf("decode access of room %q: %w", row.Name, err)
+		}
+	}
+	return rec, nil
+}
+
+func (s *GormStore) SaveRoom(rec RoomRecord) error {
+	row := DBRoom{
+		ID:          roomUUID(rec.ID),
+		Name:        rec.ID,
+		DisplayName: rec.DisplayName,
+		Owner:       rec.Owner,
+		CreatedAt:   rec.CreatedAt,
+		IsActive:    true,
+		ServerSeq:   rec.ServerSeq,
+	}
+	columns := []string{"server_seq", "updated_at"}
+	if rec.MaxPlayers > 0 {
+		row.MaxPlayers = rec.MaxPlayers
+		columns = append(columns, "max_players")
+	}
+	if rec.Rules != nil {
+		rules, err := json.Marshal(rec.Rules)
+		if err != nil {
+			return err
+		}
+		row.Rules = rules
+		columns = append(columns, "rules")
+	}
+	if rec.Access != nil {
+		access, err := json.Marshal(rec.Access)
+		if err != nil {
+			return err
+		}
+		row.Access = access
+		columns = append(columns, "access")
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&row).Error
+}
+
+func (s *GormStore) ListRooms() ([]RoomRecord, error) {
+	var rows []DBRoom
+	if err := s.db.Order("name").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list rooms: %w", err)
+	}
+	recs := make([]RoomRecord, 0, len(rows))
+	for _, row := range rows {
+		// Only rooms created by this store have IDs derived from their name
+		if row.ID != roomUUID(row.Name) {
+			continue
+		}
+		rec, err := decodeRoomRow(row)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, *rec)
+	}
+	return recs, nil
+}
+
+func (s *GormStore) LoadChunks(roomID string) ([]*Chunk, error) {
+	var rows []DBChunk
+	if err := s.db.Where("room_id = ?", roomUUID(roomID)).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("load chunks %q: %w", roomID, err)
+	}
+	chunks := make([]*Chunk, 0, len(rows))
+	for _, row := range rows {
+		ch, err := decodeChunkRow(row)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, ch)
+	}
+	return chunks, nil
+}
+
+func (s *GormStore) LoadChunk(roomID string, id ChunkID) (*Chunk, error) {
+	var row DBChunk
+	err := s.db.Where("room_id = ? AND chunk_x = ? AND chunk_y = ?", roomUUID(roomID), id.X, id.Y).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChunkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load chunk (%d,%d): %w", id.X, id.Y, err)
+	}
+	return decodeChunkRow(row)
+}
+
+func decodeChunkRow(row DBChunk) (*Chunk, error) {
+	if len(row.Packed) > 0 {
+		return PackedChunk{X: row.ChunkX, Y: row.ChunkY, Data: row.Packed}.unpack()
+	}
+	// Rows written before packing keep their cells as JSON
+	var cells map[uint32]Color
+	if err := json.Unmarshal(row.Cells, &cells); err != nil {
+		return nil, fmt.Errorf("decode chunk (%d,%d): %w", row.ChunkX, row.ChunkY, err)
+	}
+	return newChunkFromCells(row.ChunkX, row.ChunkY, cells), nil
+}
+
+func (s *GormStore) SaveChunk(roomID string, ch *Chunk) error {
+	id := roomUUID(roomID)
+	if ch.len() == 0 {
+		return s.db.Where("room_id = ? AND chunk_x = ? AND chunk_y = ?", id, ch.X, ch.Y).
+			Delete(&DBChunk{}).Error
+	}
+	row := DBChunk{
+		RoomID:     id,
+		ChunkX:     ch.X,
+		ChunkY:     ch.Y,
+		Cells:      []byte("{}"),
+		Packed:     packCells(ch, true),
+		StoneCount: ch.len(),
+	}
+	return s.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}, {Name: "chunk_x"}, {Name: "chunk_y"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cells", "packed", "stone_count", "updated_at"}),
+	}).Create(&row).Error
+}
+
+func (s *GormStore) AppendMove(roomID string, mv MoveRecord) error {
+	row := DBMove{
+		RoomID:    roomUUID(roomID),
+		Kind:      mv.Kind,
+		PlayerID:  mv.PlayerID,
+		X:         mv.X,
+		Y:         mv.Y,
+		Color:     mv.Color,
+		ServerSeq: mv.ServerSeq,
+		Accepted:  mv.Accepted,
+		CreatedAt: mv.CreatedAt,
+	}
+	return s.db.Omit(clause.Associations).Create(&row).Error
+}
+
+func (s *GormStore) LoadMoves(roomID string, fromSeq, toSeq uint64) ([]MoveRecord, error) {
+	var rows []DBMove
+	query := s.db.Where("room_id = ? AND server_seq >= ?", roomUUID(roomID), fromSeq)
+	// Drivers reject uint64 values above the signed range
+	if toSeq <= math.MaxInt64 {
+		query = query.Where("server_seq <= ?", toSeq)
+	}
+	err := query.Order("server_seq, id").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("load moves %q: %w", roomID, err)
+	}
+	moves := make([]MoveRecord, 0, len(rows))
+	for _, row := range rows {
+		moves = append(moves, MoveRecord{
+			Kind:      row.Kind,
+			ServerSeq: row.ServerSeq,
+			PlayerID:  row.PlayerID,
+			X:         row.X,
+			Y:         row.Y,
+			Color:     row.Color,
+			Accepted:  row.Accepted,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return moves, nil
+}
+
+func (s *GormStore) SaveSnapshot(roomID string, snap Snapshot) error {
+	row := DBGameState{
+		RoomID:    roomUUID(roomID),
+		ServerSeq: snap.ServerSeq,
+		StateData: snap.Data,
+		CreatedAt: snap.CreatedAt,
+	}
+	return s.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}, {Name: "server_seq"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state_data", "created_at"}),
+	}).Create(&row).Error
+}
+
+func (s *GormStore) LoadLatestSnapshot(roomID string) (*Snapshot, error) {
+	return s.loadSnapshot(s.db.Where("room_id = ?", roomUUID(roomID)), roomID)
+}
+
+func (s *GormStore) LoadSnapshotAt(roomID string, seq uint64) (*Snapshot, error) {
+	if seq > math.MaxInt64 {
+		// server_seq is a signed column; every stored seq is below this
+		return s.LoadLatestSnapshot(roomID)
+	}
+	return s.loadSnapshot(s.db.Where("room_id = ? AND server_seq <= ?", roomUUID(roomID), seq), roomID)
+}
+
+// loadSnapshot returns the newest snapshot matched by query
+func (s *GormStore) loadSnapshot(query *gorm.DB, roomID string) (*Snapshot, error) {
+	var row DBGameState
+	err := query.Order("server_seq DESC").First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot %q: %w", roomID, err)
+	}
+	return &Snapshot{ServerSeq: row.ServerSeq, Data: row.StateData, CreatedAt: row.CreatedAt}, nil
+}
+
+func (s *GormStore) ListSnapshots(roomID string) ([]Snapshot, error) {
+	var rows []DBGameState
+	err := s.db.Select("server_seq", "created_at").Where("room_id = ?", roomUUID(roomID)).
+		Order("server_seq").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots %q: %w", roomID, err)
+	}
+	snaps := make([]Snapshot, 0, len(rows))
+	for _, row := range rows {
+		snaps = append(snaps, Snapshot{ServerSeq: row.ServerSeq, CreatedAt: row.CreatedAt})
+	}
+	return snaps, nil
+}
+
+func (s *GormStore) DeleteSnapshot(roomID string, seq uint64) error {
+	return s.db.Where("room_id = ? AND server_seq = ?", roomUUID(roomID), seq).
+		Delete(&DBGameState{}).Error
+}
+
+// SaveStats keeps one players row per color, with an ID derived from the
+// room and color. The row doubles as the color's seat, see SaveSeats.
+func (s *GormStore) SaveStats(roomID string, stats []ColorStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	room := roomUUID(roomID)
+	now := time.Now()
+	rows := make([]DBPlayer, 0, len(stats))
+	for _, st := range stats {
+		color := st.Color
+		rows = append(rows, DBPlayer{
+			ID:          uuid.NewSHA1(room, []byte{byte(color)}),
+			RoomID:      room,
+			Color:       &color,
+			StoneCount:  st.Stones,
+			Captured:    st.Captured,
+			Lost:        st.Lost,
+			Moves:       st.Moves,
+			LastSeenAt:  now,
+			IsConnected: false,
+		})
+	}
+	return s.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"stone_count", "captured", "lost", "moves", "last_seen_at"}),
+	}).Create(&rows).Error
+}
+
+// SaveSeats writes the session holding each color to the color's players
+// row and clears it from rows whose color is free again
+func (s *GormStore) SaveSeats(roomID string, seats []Seat) error {
+	room := roomUUID(roomID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&DBPlayer{}).Where("room_id = ?", room).
+			Updates(map[string]interface{}{"session_id": "", "is_connected": false}).Error; err != nil {
+			return err
+		}
+		if len(seats) == 0 {
+			return nil
+		}
+		rows := make([]DBPlayer, 0, len(seats))
+		for _, seat := range seats {
+			color := seat.Color
+			rows = append(rows, DBPlayer{
+				ID:          uuid.NewSHA1(room, []byte{byte(color)}),
+				RoomID:      room,
+				SessionID:   seat.SessionID,
+				Color:       &color,
+				ConnectedAt: seat.ConnectedAt,
+				LastSeenAt:  seat.LastSeenAt,
+				IsConnected: seat.Connected,
+			})
+		}
+		return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"session_id", "connected_at", "last_seen_at", "is_connected"}),
+		}).Create(&rows).Error
+	})
+}
+
+// DeleteRoom removes the rooms row; the other tables cascade
+func (s *GormStore) DeleteRoom(roomID string) error {
+	return s.db.Delete(&DBRoom{}, "id = ?", roomUUID(roomID)).Error
+}
+
+func (s *GormStore) LoadSeats(roomID string) ([]Seat, error) {
+	var rows []DBPlayer
+	err := s.db.Where("room_id = ? AND session_id <> '' AND color IS NOT NULL", roomUUID(roomID)).
+		Order("color").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	seats := make([]Seat, 0, len(rows))
+	for _, row := range rows {
+		seats = append(seats, Seat{
+			Color:       *row.Color,
+			SessionID:   row.SessionID,
+			ConnectedAt: row.ConnectedAt,
+			LastSeenAt:  row.LastSeenAt,
+		})
+	}
+	return seats, nil
+}
+
+func (s *GormStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,427 @@
+package server
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FileStore is a Store that keeps each room in its own directory:
+//
+//	<dir>/<hex room id>/room.json
+//	<dir>/<hex room id>/chunks/<x>_<y>.json
+//	<dir>/<hex room id>/moves.jsonl
+//	<dir>/<hex room id>/snapshots/<seq>.json
+//	<dir>/<hex room id>/stats.json
+//	<dir>/<hex room id>/seats.json
+//
+// It needs no external services, which suits single-binary LAN servers.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a file store rooted at dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// roomDir hex-encodes the room ID so any string maps to a safe directory name
+func (s *FileStore) roomDir(roomID string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(roomID)))
+}
+
+func (s *FileStore) chunkPath(roomID string, id ChunkID) string {
+	return filepath.Join(s.roomDir(roomID), "chunks", fmt.Sprintf("%d_%d.json", id.X, id.Y))
+}
+
+// writeFileAtomic replaces path so readers never observe a partial write
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileStore) LoadRoom(roomID string) (*RoomRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadRoom(roomID)
+}
+
+func (s *FileStore) loadRoom(roomID string) (*RoomRecord, error) {
+	data, err := os.ReadFile(filepath.Join(s.roomDir(roomID), "room.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load room %q: %w", roomID, err)
+	}
+	var rec RoomRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decode room %q: %w", roomID, err)
+	}
+	return &rec, nil
+}
+
+func (s *FileStore) SaveRoom(rec RoomRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.roomDir(rec.ID), "room.json"), data)
+}
+
+func (s *FileStore) ListRooms() ([]RoomRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list rooms: %w", err)
+	}
+	var recs []RoomRecord
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		name, err := hex.DecodeString(e.Name())
+		if err != nil {
+			continue
+		}
+		rec, err := s.loadRoom(string(name))
+		if errors.Is(err, ErrRoomNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, *rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	return recs, nil
+}
+
+func (s *FileStore) LoadChunks(roomID string) ([]*Chunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(filepath.Join(s.roomDir(roomID), "chunks"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load chunks %q: %w", roomID, err)
+	}
+	chunks := make([]*Chunk, 0, len(entries))
+	for _, e := range entries {
+		id, ok := parseChunkFileName(e.Name())
+		if !ok {
+			continue
+		}
+		ch, err := s.loadChunk(roomID, id)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, ch)
+	}
+	return chunks, nil
+}
+
+func parseChunkFileName(name string) (ChunkID, bool) {
+	base, ok := strings.CutSuffix(name, ".json")
+	if !ok {
+		return ChunkID{}, false
+	}
+	xs, ys, ok := strings.Cut(base, "_")
+	if !ok {
+		return ChunkID{}, false
+	}
+	x, errX := strconv.ParseInt(xs, 10, 32)
+	y, errY := strconv.ParseInt(ys, 10, 32)
+	if errX != nil || errY != nil {
+		return ChunkID{}, false
+	}
+	return ChunkID{X: int32(x), Y: int32(y)}, true
+}
+
+func (s *FileStore) LoadChunk(roomID string, id ChunkID) (*Chunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadChunk(roomID, id)
+}
+
+func (s *FileStore) loadChunk(roomID string, id ChunkID) (*Chunk, error) {
+	data, err := os.ReadFile(s.chunkPath(roomID, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrChunkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load chunk (%d,%d): %w", id.X, id.Y, err)
+	}
+	var cells map[uint32]Color
+	if err := json.Unmarshal(data, &cells); err != nil {
+		return nil, fmt.Errorf("decode chunk (%d,%d): %w", id.X, id.Y, err)
+	}
+	return newChunkFromCells(id.X, id.Y, cells), nil
+}
+
+func (s *FileStore) SaveChunk(roomID string, ch *Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.chunkPath(roomID, ChunkID{X: ch.X, Y: ch.Y})
+	if ch.len() == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(ch.cellMap())
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func (s *FileStore) AppendMove(roomID string, mv MoveRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(mv)
+	if err != nil {
+		return err
+	}
+	dir := s.roomDir(roomID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "moves.jsonl"), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	torn, err := endsTorn(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if torn {
+		// End the fragment a crash left so this entry gets a line of its own
+		w.WriteByte('\n')
+	}
+	w.Write(data)
+	w.WriteByte('\n')
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// endsTorn reports whether a journal file is non-empty and does not end in
+// a newline, as when a crash cut off its last write
+func endsTorn(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+func (s *FileStore) LoadMoves(roomID string, fromSeq, toSeq uint64) ([]MoveRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(filepath.Join(s.roomDir(roomID), "moves.jsonl"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load moves %q: %w", roomID, err)
+	}
+	defer f.Close()
+
+	var moves []MoveRecord
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var mv MoveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &mv); err != nil {
+			// A line torn by a crash is expected; later entries are intact
+			log.Printf("load moves %q: skipping bad line %d: %v", roomID, line, err)
+			continue
+		}
+		if mv.ServerSeq >= fromSeq && mv.ServerSeq <= toSeq {
+			moves = append(moves, mv)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("load moves %q: %w", roomID, err)
+	}
+	return moves, nil
+}
+
+func (s *FileStore) SaveSnapshot(roomID string, snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.roomDir(roomID), "snapshots", fmt.Sprintf("%d.json", snap.ServerSeq))
+	return writeFileAtomic(path, data)
+}
+
+func (s *FileStore) LoadLatestSnapshot(roomID string) (*Snapshot, error) {
+	return s.LoadSnapshotAt(roomID, math.MaxUint64)
+}
+
+func (s *FileStore) LoadSnapshotAt(roomID string, maxSeq uint64) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Join(s.roomDir(roomID), "snapshots")
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot %q: %w", roomID, err)
+	}
+	var latest string
+	var latestSeq uint64
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(base, 10, 64)
+		if err != nil || seq > maxSeq {
+			continue
+		}
+		if latest == "" || seq > latestSeq {
+			latest, latestSeq = e.Name(), seq
+		}
+	}
+	if latest == "" {
+		return nil, ErrSnapshotNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(dir, latest))
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot %q: %w", roomID, err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot %q: %w", roomID, err)
+	}
+	return &snap, nil
+}
+
+// ListSnapshots uses file modification times as creation times so it does
+// not have to read every snapshot
+func (s *FileStore) ListSnapshots(roomID string) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(filepath.Join(s.roomDir(roomID), "snapshots"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots %q: %w", roomID, err)
+	}
+	var snaps []Snapshot
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(base, 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{ServerSeq: seq, CreatedAt: info.ModTime()})
+	}
+	return snaps, nil
+}
+
+func (s *FileStore) DeleteSnapshot(roomID string, seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Join(s.roomDir(roomID), "snapshots", fmt.Sprintf("%d.json", seq))
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) SaveStats(roomID string, stats []ColorStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.roomDir(roomID), "stats.json"), data)
+}
+
+func (s *FileStore) SaveSeats(roomID string, seats []Seat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(seats)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.roomDir(roomID), "seats.json"), data)
+}
+
+func (s *FileStore) DeleteRoom(roomID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.RemoveAll(s.roomDir(roomID))
+}
+
+func (s *FileStore) LoadSeats(roomID string) ([]Seat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join(s.roomDir(roomID), "seats.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var seats []Seat
+	if err := json.Unmarshal(data, &seats); err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
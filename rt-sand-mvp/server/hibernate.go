@@ -0,0 +1,169 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultIdleTimeout is how long an empty room stays in memory
+	DefaultIdleTimeout = 10 * time.Minute
+	// DefaultMaxLiveRooms caps the rooms held in memory at once
+	DefaultMaxLiveRooms = 1000
+)
+
+// ErrTooManyRooms is returned when the live room cap is reached and no
+// empty room can be hibernated to make space
+var ErrTooManyRooms = errors.New("too many live rooms")
+
+// idleSince reports when the room lost its last client, and whether it is
+// empty at all
+func (r *Room) idleSince() (time.Time, bool) {
+	r.clMu.RLock()
+	defer r.clMu.RUnlock()
+	return r.emptySince, len(r.clients) == 0
+}
+
+// retire makes the room refuse new clients so it can be stopped. With a
+// non-zero idleBefore it only does so if the room has been empty since
+// before then.
+func (r *Room) retire(idleBefore time.Time) bool {
+	r.clMu.Lock()
+	defer r.clMu.Unlock()
+	if !idleBefore.IsZero() && (len(r.clients) > 0 || !r.emptySince.Before(idleBefore)) {
+		return false
+	}
+	r.retired = true
+	return true
+}
+
+// idleCheckInterval is how often the manager looks for idle rooms
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	if d := timeout / 4; d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// evictIdle hibernates idle rooms until the manager's context ends
+func (rm *RoomManager) evictIdle() {
+	defer rm.wg.Done()
+	ticker := time.NewTicker(idleCheckInterval(rm.config.IdleTimeout))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rm.ctx.Done():
+			return
+		case now := <-ticker.C:
+			rm.HibernateIdle(now)
+		}
+	}
+}
+
+// HibernateIdle stops every room that has been empty for the idle timeout
+// and returns how many it stopped. The rooms flush without rm.mu held, so
+// other rooms stay usable meanwhile.
+func (rm *RoomManager) HibernateIdle(now time.Time) int {
+	rm.mu.Lock()
+	var stopped []*Room
+	for id, room := range rm.rooms {
+		if rm.hibernate(id, room, now.Add(-rm.config.IdleTimeout)) {
+			stopped = append(stopped, room)
+		}
+	}
+	rm.mu.Unlock()
+	for _, room := range stopped {
+		rm.awaitStop(room)
+	}
+	return len(stopped)
+}
+
+// loadHibernated lists the rooms saved by an earlier run as hibernated, so
+// the lobby shows them before anyone wakes them
+func (rm *RoomManager) loadHibernated() {
+	recs, err := rm.store.ListRooms()
+	if err != nil {
+		log.Printf("list saved rooms: %v", err)
+		return
+	}
+	for _, rec := range recs {
+		rm.hibernated[rec.ID] = rm.savedInfo(rec)
+	}
+}
+
+// savedInfo describes a stopped room from its record, with the configured
+// defaults for what the record lacks, as OpenRoom would restore it
+func (rm *RoomManager) savedInfo(rec RoomRecord) RoomInfo {
+	info := RoomInfo{
+		ID:          rec.ID,
+		Rules:       rm.config.Rules,
+		MaxPlayers:  rm.config.MaxPlayers,
+		DisplayName: rec.DisplayName,
+		Owner:       rec.Owner,
+		CreatedAt:   rec.CreatedAt,
+		TakenColors: ColorList{},
+		Hibernating: true,
+	}
+	if rec.Rules != nil {
+		info.Rules = *rec.Rules
+	}
+	if rec.MaxPlayers > 0 {
+		info.MaxPlayers = rec.MaxPlayers
+	}
+	if rec.Access != nil {
+		info.Private = rec.Access.Private
+	}
+	if info.DisplayName == "" {
+		info.DisplayName = rec.ID
+	}
+	return info
+}
+
+// hibernateOldest makes space for a new room by hibernating the room that
+// has been empty the longest. The caller holds rm.mu.
+func (rm *RoomManager) hibernateOldest(now time.Time) bool {
+	var oldestID string
+	var oldest *Room
+	var oldestSince time.Time
+	for id, room := range rm.rooms {
+		since, empty := room.idleSince()
+		if empty && (oldest == nil || since.Before(oldestSince)) {
+			oldestID, oldest, oldestSince = id, room, since
+		}
+	}
+	if oldest == nil || !rm.hibernate(oldestID, oldest, now) {
+		return false
+	}
+	// The caller holds rm.mu, so the flush is waited for elsewhere
+	go rm.awaitStop(oldest)
+	return true
+}
+
+// hibernate stops an empty room, which writes its state to the store, and
+// keeps it listed until it is woken. Rooms without a store would lose their
+// board, so they are never hibernated. The caller holds rm.mu.
+func (rm *RoomManager) hibernate(roomID string, room *Room, idleBefore time.Time) bool {
+	if rm.store == nil || !room.retire(idleBefore) {
+		return false
+	}
+	info := room.Info()
+	info.PlayerCount = 0
+	info.Hibernating = true
+	rm.stopRoom(roomID, room)
+	rm.hibernated[roomID] = info
+	return true
+}
+
+// makeSpace checks the live room cap before a room is started. The caller
+// holds rm.mu.
+func (rm *RoomManager) makeSpace() error {
+	max := rm.config.MaxLiveRooms
+	if max <= 0 || len(rm.rooms) < max {
+		return nil
+	}
+	if rm.hibernateOldest(time.Now()) {
+		return nil
+	}
+	return ErrTooManyRooms
+}
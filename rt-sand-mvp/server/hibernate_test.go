@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdleRoomHibernatesAndWakes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultRoomConfig()
+	config.IdleTimeout = time.Minute
+	store := NewMemoryStore()
+	saved := NewRoom()
+	if err := saved.AttachStore("sleepy", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	saved.ProcessMove(MoveRequest{X: 3, Y: 4, Color: ColorRed})
+	if err := saved.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	rm := NewRoomManager(ctx, store, config)
+
+	room, err := rm.OpenRoom("sleepy")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if n := rm.HibernateIdle(time.Now()); n != 0 {
+		t.Fatalf("room hibernated before the idle timeout")
+	}
+	if n := rm.HibernateIdle(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("expected one hibernated room, got %d", n)
+	}
+	select {
+	case <-room.done:
+	default:
+		t.Fatalf("room goroutine still running")
+	}
+	infos := rm.GetRoomInfoList()
+	if len(infos) != 1 || !infos[0].Hibernating {
+		t.Fatalf("hibernated room should stay listed, got %+v", infos)
+	}
+
+	woken, ok := rm.GetRoom("sleepy")
+	if !ok || woken == room {
+		t.Fatalf("expected a fresh room from the store")
+	}
+	if c, ok := woken.getCell(3, 4); !ok || c != ColorRed {
+		t.Fatalf("board lost across hibernation, got %v %v", c, ok)
+	}
+	if infos := rm.GetRoomInfoList(); len(infos) != 1 || infos[0].Hibernating {
+		t.Fatalf("woken room should be live, got %+v", infos)
+	}
+}
+
+func TestBusyRoomStaysAwake(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultRoomConfig()
+	config.IdleTimeout = time.Minute
+	rm := NewRoomManager(ctx, NewMemoryStore(), config)
+
+	room, _ := rm.CreateRoom("busy", RoomSettings{})
+	room.addClient(&Client{room: room})
+	if n := rm.HibernateIdle(time.Now().Add(time.Hour)); n != 0 {
+		t.Fatalf("room with a client was hibernated")
+	}
+}
+
+func TestLiveRoomCap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultRoomConfig()
+	config.MaxLiveRooms = 2
+	rm := NewRoomManager(ctx, NewMemoryStore(), config)
+
+	first, _ := rm.CreateRoom("first", RoomSettings{})
+	second, _ := rm.CreateRoom("second", RoomSettings{})
+	second.addClient(&Client{room: second})
+
+	// The empty room makes space for the new one
+	if _, err := rm.CreateRoom("third", RoomSettings{}); err != nil {
+		t.Fatalf("create over the cap: %v", err)
+	}
+	select {
+	case <-first.done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the empty room should have been hibernated")
+	}
+
+	third, _ := rm.GetRoom("third")
+	third.addClient(&Client{room: third})
+	if _, err := rm.CreateRoom("fourth", RoomSettings{}); !errors.Is(err, ErrTooManyRooms) {
+		t.Fatalf("expected ErrTooManyRooms, got %v", err)
+	}
+}
+
+// gatedStore holds chunk writes until open is closed
+type gatedStore struct {
+	Store
+	entered chan struct{}
+	open    chan struct{}
+}
+
+func (s *gatedStore) SaveChunk(roomID string, ch *Chunk) error {
+	select {
+	case s.entered <- struct{}{}:
+	default:
+	}
+	<-s.open
+	return s.Store.SaveChunk(roomID, ch)
+}
+
+func TestHibernateFlushesOutsideTheLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultRoomConfig()
+	config.IdleTimeout = time.Minute
+	config.FlushInterval = 10 * time.Millisecond
+	store := &gatedStore{Store: NewMemoryStore(), entered: make(chan struct{}, 1), open: make(chan struct{})}
+	rm := NewRoomManager(ctx, store, config)
+
+	slow, _ := rm.CreateRoom("slow", RoomSettings{})
+	slow.Inbox <- MoveRequest{X: 1, Y: 1, Color: ColorRed}
+	select {
+	case <-store.entered:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("chunk was never written")
+	}
+
+	hibernated := make(chan int)
+	go func() { hibernated <- rm.HibernateIdle(time.Now().Add(time.Hour)) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rm.ListRooms()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("room was not unlinked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Other rooms work while the slow one is flushing
+	if _, err := rm.CreateRoom("other", RoomSettings{}); err != nil {
+		t.Fatalf("create while flushing: %v", err)
+	}
+
+	// Reopening waits for the flush
+	reopened := make(chan *Room)
+	go func() {
+		room, _ := rm.GetRoom("slow")
+		reopened <- room
+	}()
+	select {
+	case <-reopened:
+		t.Fatalf("room reopened before its flush finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(store.open)
+	if n := <-hibernated; n != 1 {
+		t.Fatalf("expected one hibernated room, got %d", n)
+	}
+	room := <-reopened
+	if c, ok := room.getCell(1, 1); !ok || c != ColorRed {
+		t.Fatalf("board lost across hibernation, got %v %v", c, ok)
+	}
+}
+
+func TestSavedRoomsListedAfterRestart(t *testing.T) {
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	if _, err := rm.CreateRoom("kept", RoomSettings{DisplayName: "留下", MaxPlayers: 3}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := rm.CreateRoom("hidden", RoomSettings{Access: RoomAccess{Private: true}}); err != nil {
+		t.Fatalf("create private: %v", err)
+	}
+	cancel()
+	rm.Wait()
+
+	restarted := NewRoomManager(context.Background(), store, DefaultRoomConfig())
+	infos := restarted.GetRoomInfoList()
+	if len(infos) != 1 {
+		t.Fatalf("expected the public saved room only, got %+v", infos)
+	}
+	if info := infos[0]; info.ID != "kept" || !info.Hibernating || info.DisplayName != "留下" || info.MaxPlayers != 3 {
+		t.Fatalf("unexpected saved room %+v", info)
+	}
+	if _, err := restarted.CreateRoom("kept", RoomSettings{}); !errors.Is(err, ErrRoomExists) {
+		t.Fatalf("expected ErrRoomExists, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// journal appends an entry to the room's move journal. Entries are written
+// synchronously; when one cannot be written the room snapshots the board
+// before broadcasting the change, so recovery never replays across the gap.
+func (r *Room) journal(mv MoveRecord) {
+	if r.store == nil || r.replaying {
+		return
+	}
+	mv.CreatedAt = time.Now()
+	if err := r.store.AppendMove(r.ID, mv); err != nil {
+		log.Printf("room %s: journal %s at seq %d: %v", r.ID, mv.Kind, mv.ServerSeq, err)
+		r.journalGap = true
+	}
+}
+
+// snapshotState is the content of a snapshot: the board as packed chunks,
+// plus the counters that cannot be derived from it
+type snapshotState struct {
+	BoardState
+	Stats []ColorStats `json:"stats,omitempty"`
+}
+
+// encodeSnapshot serializes the whole board and the per-color counters
+func (r *Room) encodeSnapshot() ([]byte, error) {
+	return encodeSnapshotChunks(r.Chunks, r.copyStats(), r.Seq)
+}
+
+func encodeSnapshotChunks(chunks map[ChunkID]*Chunk, stats []ColorStats, seq uint64) ([]byte, error) {
+	return json.Marshal(snapshotState{
+		BoardState: BoardState{Packed: packChunks(chunks, true), ServerSeq: seq},
+		Stats:      stats,
+	})
+}
+
+// restoreSnapshot replaces the board with the contents of snap
+func (r *Room) restoreSnapshot(snap *Snapshot) error {
+	var state snapshotState
+	if err := json.Unmarshal(snap.Data, &state); err != nil {
+		return fmt.Errorf("decode snapshot %d: %w", snap.ServerSeq, err)
+	}
+	r.Chunks = make(map[ChunkID]*Chunk)
+	for _, p := range state.Packed {
+		ch, err := p.unpack()
+		if err != nil {
+			return fmt.Errorf("restore snapshot %d: %w", snap.ServerSeq, err)
+		}
+		r.chunkClock++
+		ch.Version = r.chunkClock
+		r.Chunks[ChunkID{X: ch.X, Y: ch.Y}] = ch
+	}
+	// Snapshots written before packing list cells instead
+	for _, c := range state.Cells {
+		if err := r.setCell(c.X, c.Y, c.Color); err != nil {
+			return fmt.Errorf("restore snapshot %d: %w", snap.ServerSeq, err)
+		}
+	}
+	r.Seq = state.ServerSeq
+	r.restoreStats(state.Stats)
+	return nil
+}
+
+// saveSnapshot writes a snapshot of the current board synchronously
+func (r *Room) saveSnapshot() error {
+	data, err := r.encodeSnapshot()
+	if err != nil {
+		return err
+	}
+	return r.store.SaveSnapshot(r.ID, Snapshot{ServerSeq: r.Seq, Data: data, CreatedAt: time.Now()})
+}
+
+// recoverFromStore rebuilds the board from the newest snapshot plus the
+// journal tail written after it.
+func (r *Room) recoverFromStore(rec *RoomRecord) error {
+	snap, err := r.store.LoadLatestSnapshot(r.ID)
+	switch {
+	case errors.Is(err, ErrSnapshotNotFound):
+		// Rooms saved before the journal existed only have chunks; snapshot
+		// them so the journal has a base to replay onto from now on.
+		chunks, err := r.store.LoadChunks(r.ID)
+		if err != nil {
+			return err
+		}
+		for _, ch := range chunks {
+			r.Chunks[ChunkID{X: ch.X, Y: ch.Y}] = ch
+		}
+		r.Seq = rec.ServerSeq
+		if err := r.saveSnapshot(); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		if err := r.restoreSnapshot(snap); err != nil {
+			return err
+		}
+	}
+
+	entries, err := r.store.LoadMoves(r.ID, r.Seq, math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	// Ko history before the snapshot is lost; replay can only allow more
+	r.rehash()
+	r.resetKo()
+	// Snapshots older than the counters only know the stones
+	r.recountStones()
+	if err := r.replay(entries, nil); err != nil {
+		return err
+	}
+	seats, err := r.store.LoadSeats(r.ID)
+	if err != nil {
+		return err
+	}
+	r.restoreSeats(seats)
+	// The chunk table may lag behind the journal; rewrite what we rebuilt
+	for id := range r.Chunks {
+		r.markDirty(id)
+	}
+	return nil
+}
+
+// recoverFromChunks rebuilds the board from the flushed chunk table alone,
+// for when the journal cannot be replayed. Changes after the last flush and
+// the counters other than stones are lost, but players get back the board
+// that was last saved rather than an empty one. The seq moves past the
+// unusable journal tail and a fresh snapshot there gives the journal a new
+// base, so later recoveries skip the tail.
+func (r *Room) recoverFromChunks(rec *RoomRecord) error {
+	chunks, err := r.store.LoadChunks(r.ID)
+	if err != nil {
+		return err
+	}
+	tail, err := r.store.LoadMoves(r.ID, rec.ServerSeq, math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	r.Chunks = make(map[ChunkID]*Chunk)
+	r.dirty = make(map[ChunkID]struct{})
+	for _, ch := range chunks {
+		r.Chunks[ChunkID{X: ch.X, Y: ch.Y}] = ch
+	}
+	r.Seq = rec.ServerSeq
+	if n := len(tail); n > 0 && tail[n-1].ServerSeq >= r.Seq {
+		r.Seq = tail[n-1].ServerSeq + 1
+	}
+	r.stats = nil
+	r.rehash()
+	r.resetKo()
+	r.recountStones()
+	seats, err := r.store.LoadSeats(r.ID)
+	if err != nil {
+		return err
+	}
+	r.restoreSeats(seats)
+	return r.saveSnapshot()
+}
+
+// replay applies journal entries on top of the current board. Moves at or
+// below the current seq are already included; resets at the current seq may
+// or may not be, but reapplying a reset is harmless since no stone can be
+// placed without advancing the seq. When emit is set it receives the delta
+// of every entry applied.
+func (r *Room) replay(entries []MoveRecord, emit func(DeltaUpdate)) error {
+	r.replaying = true
+	defer func() { r.replaying = false }()
+
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		switch e.Kind {
+		case JournalMove:
+			if e.ServerSeq <= r.Seq {
+				continue
+			}
+			if r.Rules.TickMS > 0 {
+				// A tick journals its moves under one seq; resolve them together
+				tick := []MoveRequest{{X: e.X, Y: e.Y, Color: e.Color}}
+				for i+1 < len(entries) && entries[i+1].Kind == JournalMove && entries[i+1].ServerSeq == e.ServerSeq {
+					i++
+					tick = append(tick, MoveRequest{X: entries[i].X, Y: entries[i].Y, Color: entries[i].Color})
+				}
+				results, delta := r.ResolveTick(tick)
+				for _, res := range results {
+					if !res.Accepted || res.ServerSeq != e.ServerSeq {
+						return fmt.Errorf("journal diverged at seq %d: %s", e.ServerSeq, res.Reason)
+					}
+				}
+				if emit != nil {
+					emit(delta)
+				}
+				continue
+			}
+			res := r.ProcessMove(MoveRequest{X: e.X, Y: e.Y, Color: e.Color})
+			if !res.Accepted || res.ServerSeq != e.ServerSeq {
+				return fmt.Errorf("journal diverged at seq %d: %s", e.ServerSeq, res.Reason)
+			}
+			if emit != nil {
+				emit(res.delta())
+			}
+		case JournalResetColor:
+			if e.ServerSeq >= r.Seq {
+				delta := r.ResetBoardColor(e.Color)
+				if emit != nil {
+					emit(delta)
+				}
+			}
+		case JournalReset:
+			if e.ServerSeq >= r.Seq {
+				delta := r.ResetBoard()
+				if emit != nil {
+					emit(delta)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown journal entry %q at seq %d", e.Kind, e.ServerSeq)
+		}
+	}
+	return nil
+}
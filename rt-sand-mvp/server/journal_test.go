@@ -0,0 +1,166 @@
+package server
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// playStream drives a dense random game around a chunk corner so captures
+// and resets happen, optionally snapshotting part way through.
+func playStream(t *testing.T, room *Room, moves int, snapshotAt int) {
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < moves; i++ {
+		if i == snapshotAt {
+			if err := room.saveSnapshot(); err != nil {
+				t.Fatalf("snapshot: %v", err)
+			}
+		}
+		color := Color(rng.Intn(3))
+		if rng.Intn(50) == 0 {
+			room.ResetBoardColor(color)
+			continue
+		}
+		x := int64(500 + rng.Intn(24))
+		y := int64(-12 + rng.Intn(24))
+		room.ProcessMove(MoveRequest{X: x, Y: y, Color: color})
+	}
+}
+
+func assertSameBoard(t *testing.T, want, got *Room) {
+	t.Helper()
+	if got.Seq != want.Seq {
+		t.Fatalf("expected seq %d, got %d", want.Seq, got.Seq)
+	}
+	wantCells := want.getAllCells()
+	if gotCells := got.getAllCells(); len(gotCells) != len(wantCells) {
+		t.Fatalf("expected %d cells, got %d", len(wantCells), len(gotCells))
+	}
+	for _, c := range wantCells {
+		col, ok := got.getCell(c.X, c.Y)
+		if !ok || col != c.Color {
+			t.Fatalf("cell (%d,%d): expected color %d, got %d (present=%v)", c.X, c.Y, c.Color, col, ok)
+		}
+	}
+}
+
+func TestJournalRecoversKilledRoom(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			original := NewRoom()
+			if err := original.AttachStore("journal", store); err != nil {
+				t.Fatalf("attach store: %v", err)
+			}
+			playStream(t, original, 2000, 1200)
+			// The room is abandoned here without a final Flush, as in a crash
+
+			rebuilt := NewRoom()
+			if err := rebuilt.AttachStore("journal", store); err != nil {
+				t.Fatalf("recover: %v", err)
+			}
+			assertSameBoard(t, original, rebuilt)
+		})
+	}
+}
+
+func TestJournalReplaysResetAtSnapshotSeq(t *testing.T) {
+	store := NewMemoryStore()
+	original := NewRoom()
+	if err := original.AttachStore("reset", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	original.ProcessMove(MoveRequest{X: 0, Y: 0, Color: ColorRed})
+	original.ProcessMove(MoveRequest{X: 3, Y: 3, Color: ColorBlue})
+	if err := original.saveSnapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	// Same seq as the snapshot, but taken after it
+	original.ResetBoardColor(ColorRed)
+
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("reset", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	assertSameBoard(t, original, rebuilt)
+	if rebuilt.hasStone(0, 0) {
+		t.Fatalf("reset after snapshot was not replayed")
+	}
+}
+
+// flakyJournal fails the next fail journal appends
+type flakyJournal struct {
+	Store
+	fail int
+}
+
+func (s *flakyJournal) AppendMove(roomID string, mv MoveRecord) error {
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("disk full")
+	}
+	return s.Store.AppendMove(roomID, mv)
+}
+
+func TestJournalFailureForcesSnapshot(t *testing.T) {
+	store := NewMemoryStore()
+	flaky := &flakyJournal{Store: store}
+	original := NewRoom()
+	if err := original.AttachStore("gap", flaky); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	snapQ := make(chan snapshotJob, 1)
+	play := func(x int64) {
+		original.ProcessMove(MoveRequest{X: x, Y: 0, Color: ColorGreen})
+		original.noteChange(snapQ)
+	}
+	play(0)
+	flaky.fail = 1
+	play(2)
+	play(4)
+
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("gap", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	assertSameBoard(t, original, rebuilt)
+}
+
+func TestDivergedJournalFallsBackToChunks(t *testing.T) {
+	store := NewMemoryStore()
+	original := NewRoom()
+	if err := original.AttachStore("diverged", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	original.ProcessMove(MoveRequest{X: 1, Y: 1, Color: ColorRed})
+	original.ProcessMove(MoveRequest{X: 5, Y: 5, Color: ColorBlue})
+	if err := original.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	// An entry that cannot be replayed: its cell is already taken
+	store.AppendMove("diverged", MoveRecord{Kind: JournalMove, ServerSeq: original.Seq + 1, X: 1, Y: 1, Color: ColorRed, Accepted: true})
+
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("diverged", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if rebuilt.store == nil || !rebuilt.hasStone(1, 1) || !rebuilt.hasStone(5, 5) {
+		t.Fatalf("expected the flushed board with the store attached")
+	}
+	// Play continues past the bad entry and survives another recovery
+	if res := rebuilt.ProcessMove(MoveRequest{X: 9, Y: 9, Color: ColorRed}); !res.Accepted || res.ServerSeq <= original.Seq+1 {
+		t.Fatalf("unexpected move after fallback %+v", res)
+	}
+	again := NewRoom()
+	if err := again.AttachStore("diverged", store); err != nil {
+		t.Fatalf("recover again: %v", err)
+	}
+	assertSameBoard(t, rebuilt, again)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KoRule selects how a room prevents repeated positions
+type KoRule int
+
+const (
+	KoNone    KoRule = iota // any empty point may be played
+	KoSimple                // no immediate single-stone recapture
+	KoSuperko               // positional superko, which includes simple ko
+)
+
+func (k KoRule) String() string {
+	switch k {
+	case KoSimple:
+		return "simple"
+	case KoSuperko:
+		return "superko"
+	default:
+		return "none"
+	}
+}
+
+// ParseKoRule accepts the names returned by String
+func ParseKoRule(s string) (KoRule, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none", "off":
+		return KoNone, nil
+	case "simple", "ko":
+		return KoSimple, nil
+	case "superko", "positional":
+		return KoSuperko, nil
+	}
+	return KoNone, fmt.Errorf("unknown ko rule %q", s)
+}
+
+// koKey identifies a ko between the color that captured and the color that
+// lost the stone. With many colors on one board, each pair fights its own ko.
+type koKey struct {
+	Capturer, Victim Color
+}
+
+// koState records a single-stone capture the victim may not undo right away
+type koState struct {
+	Point coord // where the captured stone stood
+	Stone coord // the capturing stone, which recapturing would remove
+}
+
+// MaxSuperkoHistory caps the positions remembered for superko. Older
+// positions are forgotten first, so a repeat of one of them is allowed.
+const MaxSuperkoHistory = 50000
+
+// koTracker holds a room's ko state; it is owned by the room goroutine
+type koTracker struct {
+	kos     map[koKey]koState
+	history map[uint64]struct{} // position hashes seen, for superko
+	order   []uint64            // history in ring order, oldest at next
+	next    int
+}
+
+// remember adds a position to the superko history, forgetting the oldest
+// once MaxSuperkoHistory positions are held
+func (k *koTracker) remember(hash uint64) {
+	if _, ok := k.history[hash]; ok {
+		return
+	}
+	if len(k.order) < MaxSuperkoHistory {
+		k.order = append(k.order, hash)
+	} else {
+		delete(k.history, k.order[k.next])
+		k.order[k.next] = hash
+		k.next = (k.next + 1) % MaxSuperkoHistory
+	}
+	k.history[hash] = struct{}{}
+}
+
+// stoneHash is the Zobrist key of one stone. The board is unbounded, so keys
+// are derived by mixing the coordinates instead of looked up in a table.
+func stoneHash(x, y int64, color Color) uint64 {
+	h := splitmix64(uint64(x))
+	h = splitmix64(h ^ uint64(y))
+	return splitmix64(h ^ uint64(color))
+}
+
+func splitmix64(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// rehash recomputes the position hash after chunks were replaced wholesale
+func (r *Room) rehash() {
+	r.hash = 0
+	for _, c := range r.getAllCells() {
+		r.hash ^= stoneHash(c.X, c.Y, c.Color)
+	}
+}
+
+// resetKo forgets ko state and position history, e.g. after a reset
+func (r *Room) resetKo() {
+	r.ko = koTracker{}
+	if r.Rules.Ko == KoSuperko {
+		r.ko.history = make(map[uint64]struct{})
+		r.ko.remember(r.hash)
+	}
+}
+
+// violatesKo reports whether a move that has already been applied, removing
+// the given stones, breaks the room's ko rule.
+func (r *Room) violatesKo(req MoveRequest, removed []Cell) bool {
+	if r.Rules.Ko == KoNone {
+		return false
+	}
+	if len(removed) == 1 {
+		c := removed[0]
+		for key, ko := range r.ko.kos {
+			if key.Victim == req.Color && key.Capturer == c.Color &&
+				ko.Point == (coord{X: req.X, Y: req.Y}) && ko.Stone == (coord{X: c.X, Y: c.Y}) {
+				return true
+			}
+		}
+	}
+	if r.Rules.Ko == KoSuperko {
+		_, seen := r.ko.history[r.hash]
+		return seen
+	}
+	return false
+}
+
+// recordKo updates ko state after an accepted move
+func (r *Room) recordKo(req MoveRequest, removed []Cell) {
+	if r.Rules.Ko == KoNone {
+		return
+	}
+	if r.Rules.Ko == KoSuperko {
+		r.ko.remember(r.hash)
+	}
+
+	// Playing elsewhere lifts the mover's bans; captured ko stones end theirs
+	for key, ko := range r.ko.kos {
+		if key.Victim == req.Color {
+			delete(r.ko.kos, key)
+			continue
+		}
+		for _, c := range removed {
+			if ko.Stone == (coord{X: c.X, Y: c.Y}) {
+				delete(r.ko.kos, key)
+				break
+			}
+		}
+	}
+
+	// A lone stone that captured one stone and sits in its only liberty
+	// starts a ko
+	if len(removed) != 1 || r.Rules.allied(removed[0].Color, req.Color) || !r.hasStone(req.X, req.Y) {
+		return
+	}
+	point := coord{X: removed[0].X, Y: removed[0].Y}
+	for _, n := range r.neighbors4(req.X, req.Y) {
+		color, ok := r.getCell(n.X, n.Y)
+		if (!ok && n != point) || (ok && r.Rules.allied(color, req.Color)) {
+			return
+		}
+	}
+	if r.ko.kos == nil {
+		r.ko.kos = make(map[koKey]koState)
+	}
+	r.ko.kos[koKey{Capturer: req.Color, Victim: removed[0].Color}] = koState{
+		Point: point,
+		Stone: coord{X: req.X, Y: req.Y},
+	}
+}
+
+// undoMove takes back a move applied by ProcessMove before it was accepted
+func (r *Room) undoMove(req MoveRequest, removed []Cell) {
+	for _, c := range removed {
+		r.setCell(c.X, c.Y, c.Color)
+	}
+	r.removeCell(req.X, req.Y)
+}
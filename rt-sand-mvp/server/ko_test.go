@@ -0,0 +1,117 @@
+package server
+
+import "testing"
+
+// playKoShape sets up a ko at (x0+1, y0) and (x0+2, y0) and lets black take
+// it, leaving white to move. It returns the point white would retake at.
+func playKoShape(t *testing.T, room *Room, x0, y0 int64) coord {
+	t.Helper()
+	moves := []MoveRequest{
+		{X: x0, Y: y0, Color: ColorBlack},
+		{X: x0 + 3, Y: y0, Color: ColorWhite},
+		{X: x0 + 1, Y: y0 - 1, Color: ColorBlack},
+		{X: x0 + 2, Y: y0 - 1, Color: ColorWhite},
+		{X: x0 + 1, Y: y0 + 1, Color: ColorBlack},
+		{X: x0 + 2, Y: y0 + 1, Color: ColorWhite},
+		{X: x0 + 1, Y: y0, Color: ColorWhite},
+	}
+	for i, m := range moves {
+		if res := room.ProcessMove(m); !res.Accepted {
+			t.Fatalf("setup move %d rejected: %s", i, res.Reason)
+		}
+	}
+	res := room.ProcessMove(MoveRequest{X: x0 + 2, Y: y0, Color: ColorBlack})
+	if !res.Accepted || len(res.Removed) != 1 {
+		t.Fatalf("black should take the ko: %+v", res)
+	}
+	return coord{X: x0 + 1, Y: y0}
+}
+
+func TestSimpleKo(t *testing.T) {
+	// The second shape straddles the chunk boundary at x=512
+	for _, x0 := range []int64{0, ChunkSize - 2} {
+		room := NewRoom()
+		room.Rules.Ko = KoSimple
+		retake := playKoShape(t, room, x0, 5)
+
+		seq := room.Seq
+		res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite})
+		if res.Accepted || res.Reason != "ko" {
+			t.Fatalf("x0=%d: immediate retake should be rejected as ko, got %+v", x0, res)
+		}
+		if room.Seq != seq || room.hasStone(retake.X, retake.Y) || !room.hasStone(retake.X+1, retake.Y) {
+			t.Fatalf("x0=%d: rejected ko left the board changed", x0)
+		}
+
+		// A third color is not part of the ko
+		if res := room.ProcessMove(MoveRequest{X: 100, Y: 100, Color: ColorRed}); !res.Accepted {
+			t.Fatalf("x0=%d: red move rejected: %s", x0, res.Reason)
+		}
+		if res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite}); res.Accepted {
+			t.Fatalf("x0=%d: another color's move should not lift white's ban", x0)
+		}
+
+		// After a threat elsewhere white may retake, and black is banned in turn
+		if res := room.ProcessMove(MoveRequest{X: -50, Y: -50, Color: ColorWhite}); !res.Accepted {
+			t.Fatalf("x0=%d: ko threat rejected: %s", x0, res.Reason)
+		}
+		if res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite}); !res.Accepted || len(res.Removed) != 1 {
+			t.Fatalf("x0=%d: retake after a threat should be allowed: %+v", x0, res)
+		}
+		if res := room.ProcessMove(MoveRequest{X: retake.X + 1, Y: retake.Y, Color: ColorBlack}); res.Reason != "ko" {
+			t.Fatalf("x0=%d: black retake should now be ko, got %+v", x0, res)
+		}
+	}
+}
+
+func TestKoDisabledAllowsRecapture(t *testing.T) {
+	room := NewRoom()
+	retake := playKoShape(t, room, 0, 0)
+	if res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite}); !res.Accepted {
+		t.Fatalf("without a ko rule the retake should be allowed: %s", res.Reason)
+	}
+}
+
+func TestSuperko(t *testing.T) {
+	room := NewRoom()
+	room.Rules.Ko = KoSuperko
+	retake := playKoShape(t, room, ChunkSize-2, -1)
+	if res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite}); res.Reason != "ko" {
+		t.Fatalf("retake repeats a position and should be ko, got %+v", res)
+	}
+
+	// A threat elsewhere changes the position, so the retake is new
+	if res := room.ProcessMove(MoveRequest{X: 40, Y: 40, Color: ColorWhite}); !res.Accepted {
+		t.Fatalf("threat rejected: %s", res.Reason)
+	}
+	if res := room.ProcessMove(MoveRequest{X: retake.X, Y: retake.Y, Color: ColorWhite}); !res.Accepted {
+		t.Fatalf("retake after a threat should be allowed: %s", res.Reason)
+	}
+
+	want := room.hash
+	room.rehash()
+	if room.hash != want {
+		t.Fatalf("incremental hash %x differs from full hash %x", want, room.hash)
+	}
+}
+
+func TestSuperkoHistoryIsBounded(t *testing.T) {
+	room := NewRoom()
+	room.Rules.Ko = KoSuperko
+	room.resetKo()
+	for i := uint64(1); i <= MaxSuperkoHistory+10; i++ {
+		room.ko.remember(i)
+	}
+	if len(room.ko.history) != MaxSuperkoHistory {
+		t.Fatalf("expected %d remembered positions, got %d", MaxSuperkoHistory, len(room.ko.history))
+	}
+	// The empty board and the oldest moves were forgotten first
+	for _, old := range []uint64{0, 1, 10} {
+		if _, ok := room.ko.history[old]; ok {
+			t.Fatalf("position %d should have been forgotten", old)
+		}
+	}
+	if _, ok := room.ko.history[MaxSuperkoHistory+10]; !ok {
+		t.Fatalf("the newest position was forgotten")
+	}
+}
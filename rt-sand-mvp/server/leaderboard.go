@@ -0,0 +1,154 @@
+package server
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// DefaultLeaderboardInterval is how often changed counters are pushed
+const DefaultLeaderboardInterval = time.Second
+
+// ColorStats are the running counters of one color in a room
+type ColorStats struct {
+	Color    Color `json:"color"`
+	Stones   int   `json:"stones"`   // on the board now
+	Captured int   `json:"captured"` // enemy stones this color has captured
+	Lost     int   `json:"lost"`     // own stones removed by captures or suicide
+	Moves    int   `json:"moves"`    // accepted moves
+}
+
+// Leaderboard ranks the colors of a room by stones on the board
+type Leaderboard struct {
+	Colors    []ColorStats `json:"colors"`
+	ServerSeq uint64       `json:"server_seq"`
+}
+
+// LeaderboardRequest asks the room goroutine for the current leaderboard.
+// WebSocket clients get a leaderboard envelope; HTTP handlers set Reply.
+type LeaderboardRequest struct {
+	Player *Client
+	Reply  chan Leaderboard
+}
+
+func (r *Room) statsFor(color Color) *ColorStats {
+	if r.stats == nil {
+		r.stats = make(map[Color]*ColorStats)
+	}
+	s, ok := r.stats[color]
+	if !ok {
+		s = &ColorStats{Color: color}
+		r.stats[color] = s
+	}
+	return s
+}
+
+// countMove updates the counters for an accepted move
+func (r *Room) countMove(req MoveRequest, captured, removed []Cell) {
+	mover := r.statsFor(req.Color)
+	mover.Moves++
+	mover.Stones++
+	mover.Captured += len(captured)
+	for _, c := range removed {
+		s := r.statsFor(c.Color)
+		s.Stones--
+		s.Lost++
+	}
+	r.statsChanged()
+}
+
+// recountStones sets the stone counters from the board, e.g. after the
+// board was restored
+func (r *Room) recountStones() {
+	for _, s := range r.stats {
+		s.Stones = 0
+	}
+	for _, ch := range r.Chunks {
+		ch.each(func(_ uint32, color Color) { r.statsFor(color).Stones++ })
+	}
+	r.statsChanged()
+}
+
+func (r *Room) statsChanged() {
+	r.statsPending = true
+	if r.store != nil {
+		r.statsDirty = true
+	}
+}
+
+// copyStats returns the counters ordered by color
+func (r *Room) copyStats() []ColorStats {
+	stats := make([]ColorStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Color < stats[j].Color })
+	return stats
+}
+
+func (r *Room) restoreStats(stats []ColorStats) {
+	r.stats = make(map[Color]*ColorStats, len(stats))
+	for _, s := range stats {
+		s := s
+		r.stats[s.Color] = &s
+	}
+}
+
+// Leaderboard returns colors that have played, most stones first. Must be
+// called on the room goroutine.
+func (r *Room) Leaderboard() Leaderboard {
+	board := Leaderboard{Colors: make([]ColorStats, 0, len(r.stats)), ServerSeq: r.Seq}
+	for _, s := range r.copyStats() {
+		if s != (ColorStats{Color: s.Color}) {
+			board.Colors = append(board.Colors, s)
+		}
+	}
+	sort.SliceStable(board.Colors, func(i, j int) bool {
+		a, b := board.Colors[i], board.Colors[j]
+		if a.Stones != b.Stones {
+			return a.Stones > b.Stones
+		}
+		return a.Captured > b.Captured
+	})
+	return board
+}
+
+// pushLeaderboard sends the leaderboard to every client if it changed
+// since the last push
+func (r *Room) pushLeaderboard() {
+	if !r.statsPending {
+		return
+	}
+	r.statsPending = false
+	board := r.Leaderboard()
+	env := Envelope{Type: "leaderboard", Leaderboard: &board}
+	var payloads [2][]byte
+	r.clMu.RLock()
+	defer r.clMu.RUnlock()
+	for c := range r.clients {
+		i := 0
+		if c.binary {
+			i = 1
+		}
+		if payloads[i] == nil {
+			payload, err := encodeEnvelope(env, c.binary)
+			if err != nil {
+				log.Printf("leaderboard marshal: %v", err)
+				return
+			}
+			payloads[i] = payload
+		}
+		c.deliver(payloads[i])
+	}
+}
+
+func (r *Room) serveLeaderboard(req LeaderboardRequest) {
+	board := r.Leaderboard()
+	if req.Reply != nil {
+		req.Reply <- board
+		return
+	}
+	if req.Player != nil {
+		req.Player.sendEnvelope(Envelope{Type: "leaderboard", Leaderboard: &board})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLeaderboardCounters(t *testing.T) {
+	room := NewRoom()
+	// Black captures the white stone at (0,0)
+	for _, m := range []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+		{X: 0, Y: -1, Color: ColorBlack},
+		{X: 9, Y: 9, Color: ColorRed},
+	} {
+		room.ProcessMove(m)
+	}
+	board := room.Leaderboard()
+	want := []ColorStats{
+		{Color: ColorBlack, Stones: 4, Captured: 1, Moves: 4},
+		{Color: ColorRed, Stones: 1, Moves: 1},
+		{Color: ColorWhite, Lost: 1, Moves: 1},
+	}
+	if !reflect.DeepEqual(board.Colors, want) {
+		t.Fatalf("unexpected leaderboard %+v", board.Colors)
+	}
+
+	// Occupied points are rejected and not counted
+	room.ProcessMove(MoveRequest{X: 9, Y: 9, Color: ColorBlack})
+	room.ResetBoardColor(ColorBlack)
+	black := room.Leaderboard().Colors[1]
+	if black.Color != ColorBlack || black.Stones != 0 || black.Moves != 4 || black.Captured != 1 {
+		t.Fatalf("unexpected counters after reset %+v", black)
+	}
+}
+
+func TestLeaderboardPush(t *testing.T) {
+	room := NewRoom()
+	c := &Client{room: room, send: make(chan []byte, 4)}
+	room.addClient(c)
+
+	room.pushLeaderboard()
+	if len(c.send) != 0 {
+		t.Fatalf("nothing changed, nothing should be pushed")
+	}
+	room.ProcessMove(MoveRequest{X: 1, Y: 1, Color: ColorPink})
+	room.ProcessMove(MoveRequest{X: 2, Y: 2, Color: ColorPink})
+	room.pushLeaderboard()
+	room.pushLeaderboard()
+	if len(c.send) != 1 {
+		t.Fatalf("expected one throttled push, got %d", len(c.send))
+	}
+	var env Envelope
+	if err := json.Unmarshal(<-c.send, &env); err != nil || env.Type != "leaderboard" {
+		t.Fatalf("unexpected message %+v: %v", env, err)
+	}
+	if got := env.Leaderboard.Colors; len(got) != 1 || got[0].Stones != 2 {
+		t.Fatalf("unexpected leaderboard %+v", got)
+	}
+}
+
+func TestLeaderboardSurvivesRecovery(t *testing.T) {
+	store := NewMemoryStore()
+	original := NewRoom()
+	if err := original.AttachStore("stats", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		original.ProcessMove(MoveRequest{X: int64(i), Y: 0, Color: ColorOrange})
+	}
+	if err := original.saveSnapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	// Captured after the snapshot, so the journal has to replay it
+	original.ProcessMove(MoveRequest{X: 20, Y: 0, Color: ColorCyan})
+	for _, m := range []MoveRequest{{X: 21, Y: 0}, {X: 19, Y: 0}, {X: 20, Y: 1}, {X: 20, Y: -1}} {
+		m.Color = ColorOrange
+		original.ProcessMove(m)
+	}
+	if err := original.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := store.stats["stats"]; len(got) != 2 || got[0].Color != ColorOrange || got[0].Stones != 8 {
+		t.Fatalf("unexpected saved stats %+v", got)
+	}
+
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("stats", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if !reflect.DeepEqual(rebuilt.Leaderboard().Colors, original.Leaderboard().Colors) {
+		t.Fatalf("counters differ after recovery:\n%+v\n%+v", rebuilt.Leaderboard().Colors, original.Leaderboard().Colors)
+	}
+}
@@ -10,13 +10,17 @@ import (
 // DBRoom represents a room in the database
 type DBRoom struct {
 	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Name           string    `gorm:"type:varchar(255);not null"`
+	Name           string    `gorm:"type:varchar(255);not null"` // room ID, see roomIDPattern
+	DisplayName    string    `gorm:"type:varchar(255);not null;default:''"`
+	Owner          string    `gorm:"type:varchar(255);not null;default:''"`
 	CreatedAt      time.Time `gorm:"not null;default:now()"`
 	UpdatedAt      time.Time `gorm:"not null;default:now()"`
 	IsActive       bool      `gorm:"not null;default:true"`
 	MaxPlayers     int       `gorm:"default:5"`
 	CurrentPlayers int       `gorm:"default:0"`
 	ServerSeq      uint64    `gorm:"not null;default:0"`
+	Rules          []byte    `gorm:"type:jsonb"` // RuleSet, NULL for rooms created before rule sets
+	Access         []byte    `gorm:"type:jsonb"` // RoomAccess of private rooms, NULL for public ones
 }
 
 // TableName specifies the table name for DBRoom
@@ -27,7 +31,7 @@ func (DBRoom) TableName() string {
 // DBGameState represents a snapshot of the game state
 type DBGameState struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	RoomID    uuid.UUID `gorm:"type:uuid;not null;index:idx_game_states_room"`
+	RoomID    uuid.UUID `gorm:"type:uuid;not null;index:idx_game_states_room;uniqueIndex:idx_room_seq"`
 	ServerSeq uint64    `gorm:"not null;uniqueIndex:idx_room_seq"`
 	StateData []byte    `gorm:"type:jsonb;not null"` // Stores serialized game state
 	CreatedAt time.Time `gorm:"not null;default:now()"`
@@ -45,7 +49,8 @@ type DBChunk struct {
 	RoomID     uuid.UUID `gorm:"type:uuid;not null;index:idx_chunks_room;uniqueIndex:idx_room_chunk_coords"`
 	ChunkX     int32     `gorm:"not null;uniqueIndex:idx_room_chunk_coords"`
 	ChunkY     int32     `gorm:"not null;uniqueIndex:idx_room_chunk_coords"`
-	Cells      []byte    `gorm:"type:jsonb;not null;default:'{}'"`
+	Cells      []byte    `gorm:"type:jsonb;not null;default:'{}'"` // legacy rows only
+	Packed     []byte    `gorm:"type:bytea"`                         // see packed.go
 	StoneCount int       `gorm:"not null;default:0"`
 	CreatedAt  time.Time `gorm:"not null;default:now()"`
 	UpdatedAt  time.Time `gorm:"not null;default:now()"`
@@ -61,6 +66,7 @@ func (DBChunk) TableName() string {
 type DBMove struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
 	RoomID    uuid.UUID `gorm:"type:uuid;not null;index:idx_moves_room"`
+	Kind      string    `gorm:"type:varchar(16);not null;default:move"` // move, reset_color or reset
 	PlayerID  string    `gorm:"type:varchar(255)"`
 	X         int64     `gorm:"not null"`
 	Y         int64     `gorm:"not null"`
@@ -83,6 +89,9 @@ type DBPlayer struct {
 	SessionID    string    `gorm:"type:varchar(255);not null;index:idx_players_session"`
 	Color        *Color    `gorm:"type:smallint"`
 	StoneCount   int       `gorm:"not null;default:0"`
+	Captured     int       `gorm:"not null;default:0"`
+	Lost         int       `gorm:"not null;default:0"`
+	Moves        int       `gorm:"not null;default:0"`
 	ConnectedAt  time.Time `gorm:"not null;default:now()"`
 	LastSeenAt   time.Time `gorm:"not null;default:now()"`
 	IsConnected  bool      `gorm:"not null;default:true"`
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Packed chunk format. A flags byte is followed by runs of equal-colored
+// cells in local index order, each encoded as
+//
+//	uvarint gap<<1 | long   gap is the cells skipped since the previous run
+//	uvarint length - 2      only present when long is set
+//	byte    color
+//
+// so a lone stone usually costs two or three bytes. With packedDeflate set, everything after the flags byte is raw deflate.
+const (
+	packedDeflate byte = 1 << 0
+)
+
+var ErrCorruptChunk = errors.New("corrupt packed chunk")
+
+// PackedChunk is one chunk in the packed format
+type PackedChunk struct {
+	X    int32  `json:"x"`
+	Y    int32  `json:"y"`
+	Data []byte `json:"data"`
+}
+
+// packCells encodes the chunk's stones as runs. compress deflates the
+// result when that makes it smaller; it is meant for storage, transfer
+// leaves it to the WebSocket layer.
+func packCells(ch *Chunk, compress bool) []byte {
+	type stone struct {
+		idx   uint32
+		color Color
+	}
+	stones := make([]stone, 0, ch.len())
+	ch.each(func(idx uint32, color Color) { stones = append(stones, stone{idx, color}) })
+	if ch.dense == nil {
+		sort.Slice(stones, func(i, j int) bool { return stones[i].idx < stones[j].idx })
+	}
+
+	out := []byte{0}
+	next := uint32(0) // first index after the previous run
+	for i := 0; i < len(stones); {
+		start, color := stones[i].idx, stones[i].color
+		j := i + 1
+		for j < len(stones) && stones[j].idx == stones[j-1].idx+1 && stones[j].color == color {
+			j++
+		}
+		gap := uint64(start-next) << 1
+		if j-i > 1 {
+			out = binary.AppendUvarint(out, gap|1)
+			out = binary.AppendUvarint(out, uint64(j-i-2))
+		} else {
+			out = binary.AppendUvarint(out, gap)
+		}
+		out = append(out, byte(color))
+		next = start + uint32(j-i)
+		i = j
+	}
+	if !compress || len(out) < 64 {
+		return out
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(packedDeflate)
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write(out[1:])
+	w.Close()
+	if buf.Len() >= len(out) {
+		return out
+	}
+	return buf.Bytes()
+}
+
+// unpackCells decodes data written by packCells into a cell map
+func unpackCells(data []byte) (map[uint32]Color, error) {
+	if len(data) == 0 {
+		return nil, ErrCorruptChunk
+	}
+	flags, body := data[0], data[1:]
+	if flags&packedDeflate != 0 {
+		raw, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(body)), 4*chunkCellCount))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptChunk, err)
+		}
+		body = raw
+	}
+
+	cells := make(map[uint32]Color)
+	next := uint64(0)
+	for len(body) > 0 {
+		head, n := binary.Uvarint(body)
+		if n <= 0 {
+			return nil, ErrCorruptChunk
+		}
+		body = body[n:]
+		gap, length := head>>1, uint64(1)
+		if head&1 != 0 {
+			extra, n := binary.Uvarint(body)
+			if n <= 0 {
+				return nil, ErrCorruptChunk
+			}
+			body = body[n:]
+			length = extra + 2
+		}
+		if len(body) == 0 {
+			return nil, ErrCorruptChunk
+		}
+		color := Color(body[0])
+		body = body[1:]
+
+		start := next + gap
+		end := start + length
+		if gap >= chunkCellCount || length > chunkCellCount || end > chunkCellCount {
+			return nil, ErrCorruptChunk
+		}
+		for idx := start; idx < end; idx++ {
+			cells[uint32(idx)] = color
+		}
+		next = end
+	}
+	return cells, nil
+}
+
+func (c *Chunk) pack(compress bool) PackedChunk {
+	return PackedChunk{X: c.X, Y: c.Y, Data: packCells(c, compress)}
+}
+
+func (p PackedChunk) unpack() (*Chunk, error) {
+	cells, err := unpackCells(p.Data)
+	if err != nil {
+		return nil, fmt.Errorf("chunk (%d,%d): %w", p.X, p.Y, err)
+	}
+	return newChunkFromCells(p.X, p.Y, cells), nil
+}
+
+// packChunks packs every non-empty chunk, ordered by position
+func packChunks(chunks map[ChunkID]*Chunk, compress bool) []PackedChunk {
+	packed := make([]PackedChunk, 0, len(chunks))
+	for _, ch := range chunks {
+		if ch.len() > 0 {
+			packed = append(packed, ch.pack(compress))
+		}
+	}
+	sort.Slice(packed, func(i, j int) bool {
+		a, b := packed[i], packed[j]
+		return a.X < b.X || (a.X == b.X && a.Y < b.Y)
+	})
+	return packed
+}
+
+// packed returns the state with its cells regrouped into packed chunks,
+// which is how board_state goes over the wire.
+func (s BoardState) packed() BoardState {
+	chunks := make(map[ChunkID]*Chunk)
+	for _, c := range s.Cells {
+		id, err := chunkIDFor(c.X, c.Y)
+		if err != nil {
+			continue
+		}
+		ch, ok := chunks[id]
+		if !ok {
+			ch = newChunk(id.X, id.Y)
+			chunks[id] = ch
+		}
+		ch.set(localIndex(c.X, c.Y), c.Color)
+	}
+	return BoardState{
+		Chunks:    s.Chunks,
+		Packed:    packChunks(chunks, false),
+		ServerSeq: s.ServerSeq,
+	}
+}
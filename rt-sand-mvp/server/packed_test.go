@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestPackedChunkRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	sparse := make(map[uint32]Color)
+	for i := 0; i < 2000; i++ {
+		sparse[uint32(rng.Intn(chunkCellCount))] = Color(rng.Intn(10))
+	}
+	dense := make(map[uint32]Color)
+	for idx := uint32(0); idx < 80*ChunkSize; idx++ {
+		dense[idx] = Color(idx / 700 % 3)
+	}
+	edges := map[uint32]Color{0: ColorBlack, chunkCellCount - 1: emptyCell - 1}
+
+	for name, cells := range map[string]map[uint32]Color{"sparse": sparse, "dense": dense, "edges": edges, "empty": {}} {
+		for _, compress := range []bool{false, true} {
+			ch := newChunkFromCells(0, 0, cells)
+			got, err := unpackCells(packCells(ch, compress))
+			if err != nil {
+				t.Fatalf("%s (compress=%v): %v", name, compress, err)
+			}
+			if len(got) != len(cells) {
+				t.Fatalf("%s (compress=%v): expected %d cells, got %d", name, compress, len(cells), len(got))
+			}
+			for idx, color := range cells {
+				if got[idx] != color {
+					t.Fatalf("%s (compress=%v): cell %d is %d, want %d", name, compress, idx, got[idx], color)
+				}
+			}
+		}
+	}
+}
+
+func TestPackedBoardStateIsSmaller(t *testing.T) {
+	room := NewRoom()
+	// A crowded battle area straddling chunk corners
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		room.setCell(int64(rng.Intn(400))-200, int64(rng.Intn(400))-200, Color(rng.Intn(2)))
+	}
+	state := room.GetBoardState()
+	plain, _ := json.Marshal(state)
+	packed, _ := json.Marshal(state.packed())
+	if len(packed)*10 > len(plain) {
+		t.Fatalf("packed state is %d bytes, plain is %d", len(packed), len(plain))
+	}
+
+	// The packed state rebuilds the same board
+	rebuilt := NewRoom()
+	for _, p := range state.packed().Packed {
+		ch, err := p.unpack()
+		if err != nil {
+			t.Fatalf("unpack: %v", err)
+		}
+		rebuilt.Chunks[ChunkID{X: ch.X, Y: ch.Y}] = ch
+	}
+	for _, c := range state.Cells {
+		if color, ok := rebuilt.getCell(c.X, c.Y); !ok || color != c.Color {
+			t.Fatalf("cell (%d,%d) lost in packing", c.X, c.Y)
+		}
+	}
+}
+
+func TestUnpackRejectsCorruptData(t *testing.T) {
+	bad := [][]byte{
+		nil,
+		{0, 0x80},                // truncated varint
+		{0, 1, 0},                // run without color
+		{0, 0xff, 0xff, 0x7f, 1}, // gap beyond the chunk
+		{packedDeflate, 1, 2, 3}, // not deflate
+	}
+	for i, data := range bad {
+		if _, err := unpackCells(data); !errors.Is(err, ErrCorruptChunk) {
+			t.Fatalf("case %d: expected ErrCorruptChunk, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultFlushInterval is how often a room writes dirty chunks to its store
+const DefaultFlushInterval = 2 * time.Second
+
+// flushBatch is a copy of the room state that changed since the last flush
+type flushBatch struct {
+	chunks []*Chunk
+	stats  []ColorStats // nil when the counters did not change
+	seats  []Seat       // nil when the seats did not change
+	seq    uint64
+}
+
+// merge combines an older failed batch with a newer one, newer chunks winning
+func (b flushBatch) merge(next flushBatch) flushBatch {
+	byID := make(map[ChunkID]*Chunk, len(b.chunks)+len(next.chunks))
+	for _, ch := range b.chunks {
+		byID[ChunkID{X: ch.X, Y: ch.Y}] = ch
+	}
+	for _, ch := range next.chunks {
+		byID[ChunkID{X: ch.X, Y: ch.Y}] = ch
+	}
+	merged := flushBatch{chunks: make([]*Chunk, 0, len(byID)), stats: next.stats, seats: next.seats, seq: next.seq}
+	if merged.stats == nil {
+		merged.stats = b.stats
+	}
+	if merged.seats == nil {
+		merged.seats = b.seats
+	}
+	for _, ch := range byID {
+		merged.chunks = append(merged.chunks, ch)
+	}
+	return merged
+}
+
+// AttachStore rehydrates the room from store and enables journaling and
+// background flushing. It must be called before Run.
+func (r *Room) AttachStore(roomID string, store Store) error {
+	r.ID = roomID
+
+	rec, err := store.LoadRoom(roomID)
+	if errors.Is(err, ErrRoomNotFound) {
+		if err := store.SaveRoom(r.record(r.Seq)); err != nil {
+			return err
+		}
+		// Give the journal a base snapshot to replay onto
+		r.store = store
+		if err := r.saveSnapshot(); err != nil {
+			r.store = nil
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if rec.Rules != nil {
+		r.Rules = *rec.Rules
+	}
+	if rec.MaxPlayers > 0 {
+		r.MaxPlayers = rec.MaxPlayers
+	}
+	if rec.Access != nil {
+		r.Access = *rec.Access
+	}
+	r.DisplayName, r.Owner = rec.DisplayName, rec.Owner
+	if !rec.CreatedAt.IsZero() {
+		r.CreatedAt = rec.CreatedAt
+	}
+	r.store = store
+	if err := r.recoverFromStore(rec); err != nil {
+		log.Printf("room %s: recover from journal: %v; using the saved chunks", roomID, err)
+		if err = r.recoverFromChunks(rec); err == nil {
+			return nil
+		}
+		// Run detached rather than overwrite saved state with a partial board
+		r.store = nil
+		r.Chunks = make(map[ChunkID]*Chunk)
+		r.dirty = make(map[ChunkID]struct{})
+		r.Seq = 0
+		return err
+	}
+	r.savedSeq = rec.ServerSeq
+	return nil
+}
+
+func (r *Room) markDirty(id ChunkID) {
+	if r.store == nil {
+		return
+	}
+	r.dirty[id] = struct{}{}
+}
+
+// takeDirty copies every dirty chunk; chunks that were emptied are
+// returned without cells so the store deletes them.
+func (r *Room) takeDirty() (flushBatch, bool) {
+	if len(r.dirty) == 0 && r.Seq == r.savedSeq && !r.statsDirty && !r.seatsDirty {
+		return flushBatch{}, false
+	}
+	batch := flushBatch{chunks: make([]*Chunk, 0, len(r.dirty)), seq: r.Seq}
+	if r.statsDirty {
+		batch.stats = r.copyStats()
+	}
+	if r.seatsDirty {
+		batch.seats = r.copySeats()
+	}
+	for id := range r.dirty {
+		if ch, ok := r.Chunks[id]; ok {
+			batch.chunks = append(batch.chunks, ch.clone())
+		} else {
+			batch.chunks = append(batch.chunks, &Chunk{X: id.X, Y: id.Y})
+		}
+	}
+	return batch, true
+}
+
+func (r *Room) commitDirty(batch flushBatch) {
+	r.dirty = make(map[ChunkID]struct{})
+	r.savedSeq = batch.seq
+	r.statsDirty = false
+	r.seatsDirty = false
+}
+
+func (r *Room) writeBatch(batch flushBatch) error {
+	for _, ch := range batch.chunks {
+		if err := r.store.SaveChunk(r.ID, ch); err != nil {
+			return fmt.Errorf("save chunk (%d,%d): %w", ch.X, ch.Y, err)
+		}
+	}
+	if batch.stats != nil {
+		if err := r.store.SaveStats(r.ID, batch.stats); err != nil {
+			return fmt.Errorf("save stats: %w", err)
+		}
+	}
+	if batch.seats != nil {
+		if err := r.store.SaveSeats(r.ID, batch.seats); err != nil {
+			return fmt.Errorf("save seats: %w", err)
+		}
+	}
+	return r.store.SaveRoom(r.record(batch.seq))
+}
+
+// record is the room's metadata as of seq. Rules, capacity and access never
+// change after creation, so the flush worker may call it too.
+func (r *Room) record(seq uint64) RoomRecord {
+	rules := r.Rules
+	rec := RoomRecord{
+		ID:          r.ID,
+		ServerSeq:   seq,
+		Rules:       &rules,
+		MaxPlayers:  r.MaxPlayers,
+		DisplayName: r.DisplayName,
+		Owner:       r.Owner,
+		CreatedAt:   r.CreatedAt,
+	}
+	if r.Access.Private {
+		access := r.Access
+		rec.Access = &access
+	}
+	return rec
+}
+
+// Flush synchronously writes all pending changes to the store. It must not
+// be called while Run is active.
+func (r *Room) Flush() error {
+	if r.store == nil {
+		return nil
+	}
+	batch, ok := r.takeDirty()
+	if !ok {
+		return nil
+	}
+	if err := r.writeBatch(batch); err != nil {
+		return err
+	}
+	r.commitDirty(batch)
+	return nil
+}
+
+// flushWorker writes batches off the room goroutine, retrying failed
+// batches together with the next one.
+func (r *Room) flushWorker(queue <-chan flushBatch, done chan<- struct{}) {
+	defer close(done)
+	var pending *flushBatch
+	for batch := range queue {
+		if pending != nil {
+			batch = pending.merge(batch)
+		}
+		if err := r.writeBatch(batch); err != nil {
+			log.Printf("room %s: flush: %v", r.ID, err)
+			pending = &batch
+			continue
+		}
+		pending = nil
+	}
+	if pending != nil {
+		log.Printf("room %s: dropping %d unsaved chunks", r.ID, len(pending.chunks))
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFlushAndRehydrate(t *testing.T) {
+	store := NewMemoryStore()
+	room := NewRoom()
+	if err := room.AttachStore("persist", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+
+	moves := []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+		{X: 0, Y: -1, Color: ColorBlack},
+		{X: 1, Y: 0, Color: ColorBlack}, // captures the white stone
+		{X: 600, Y: -700, Color: ColorRed},
+	}
+	for i, m := range moves {
+		if res := room.ProcessMove(m); !res.Accepted {
+			t.Fatalf("move %d rejected: %v", i, res.Reason)
+		}
+	}
+	if err := room.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	rm := NewRoomManager(context.Background(), store, DefaultRoomConfig())
+	restored, _ := rm.OpenRoom("persist")
+	if restored.Seq != room.Seq {
+		t.Fatalf("expected seq %d, got %d", room.Seq, restored.Seq)
+	}
+	want := room.getAllCells()
+	if got := restored.getAllCells(); len(got) != len(want) {
+		t.Fatalf("expected %d cells, got %d", len(want), len(got))
+	}
+	for _, c := range want {
+		col, ok := restored.getCell(c.X, c.Y)
+		if !ok || col != c.Color {
+			t.Fatalf("cell (%d,%d) not restored", c.X, c.Y)
+		}
+	}
+	if restored.hasStone(0, 0) {
+		t.Fatalf("captured stone was persisted")
+	}
+}
+
+func TestFlushDeletesEmptiedChunks(t *testing.T) {
+	store := NewMemoryStore()
+	room := NewRoom()
+	if err := room.AttachStore("reset", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	room.ProcessMove(MoveRequest{X: 5, Y: 5, Color: ColorBlue})
+	if err := room.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	room.ResetBoardColor(ColorBlue)
+	if err := room.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	chunks, err := store.LoadChunks("reset")
+	if err != nil {
+		t.Fatalf("load chunks: %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("expected emptied chunk to be deleted, got %d chunks", len(chunks))
+	}
+}
+
+func TestZeroFlushIntervalFlushesOnStop(t *testing.T) {
+	store := NewMemoryStore()
+	config := DefaultRoomConfig()
+	config.FlushInterval = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	rm := NewRoomManager(ctx, store, config)
+	room, err := rm.CreateRoom("lazy", RoomSettings{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	room.Inbox <- MoveRequest{X: 2, Y: 3, Color: ColorBlue}
+	// The journal entry shows the move was played
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if moves, _ := store.LoadMoves("lazy", 0, math.MaxUint64); len(moves) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("move was never played")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	rm.Wait()
+
+	restored, _ := NewRoomManager(context.Background(), store, config).OpenRoom("lazy")
+	if c, ok := restored.getCell(2, 3); !ok || c != ColorBlue {
+		t.Fatalf("stone not flushed on stop, got %v %v", c, ok)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultInviteTTL is how long an invite link works unless asked otherwise
+	DefaultInviteTTL = 24 * time.Hour
+	// MaxInviteTTL bounds the lifetime of an invite link
+	MaxInviteTTL = 7 * 24 * time.Hour
+	// AccessTokenTTL is how long the token bought with a password works
+	AccessTokenTTL = 12 * time.Hour
+	// MaxPasswordLength is the most bcrypt looks at
+	MaxPasswordLength = 72
+)
+
+var (
+	// ErrAccessDenied is returned for private rooms without a valid
+	// password or invite
+	ErrAccessDenied = errors.New("access_denied")
+	// ErrNotOwner is returned when an invite is asked for without the
+	// room's owner key
+	ErrNotOwner = errors.New("not the room owner")
+)
+
+// RoomAccess guards a private room. It is fixed when the room is created.
+// Private rooms are left out of the room list and can only be joined with
+// the password or an invite signed with InviteSecret.
+type RoomAccess struct {
+	Private      bool   `json:"private"`
+	PasswordHash []byte `json:"password_hash,omitempty"` // bcrypt, nil for invite-only rooms
+	OwnerKeyHash []byte `json:"owner_key_hash,omitempty"`
+	InviteSecret []byte `json:"invite_secret,omitempty"`
+}
+
+// newRoomAccess makes the access rules of a private room and returns them
+// with the owner key, which is only ever shown to the creator
+func newRoomAccess(password string) (RoomAccess, string, error) {
+	if len(password) > MaxPasswordLength {
+		return RoomAccess{}, "", errors.New("password is too long")
+	}
+	access := RoomAccess{Private: true, InviteSecret: make([]byte, 32)}
+	if _, err := rand.Read(access.InviteSecret); err != nil {
+		return RoomAccess{}, "", err
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return RoomAccess{}, "", err
+		}
+		access.PasswordHash = hash
+	}
+	ownerKey := newSessionToken()
+	sum := sha256.Sum256([]byte(ownerKey))
+	access.OwnerKeyHash = sum[:]
+	return access, ownerKey, nil
+}
+
+// admits reports whether a connection with the given password or invite
+// may enter the room
+func (a *RoomAccess) admits(roomID, password, invite string, now time.Time) bool {
+	if !a.Private {
+		return true
+	}
+	if invite != "" && a.validInvite(roomID, invite, now) {
+		return true
+	}
+	return password != "" && a.PasswordHash != nil &&
+		bcrypt.CompareHashAndPassword(a.PasswordHash, []byte(password)) == nil
+}
+
+// isOwner checks an owner key
+func (a *RoomAccess) isOwner(key string) bool {
+	if key == "" || a.OwnerKeyHash == nil {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+	return subtle.ConstantTimeCompare(sum[:], a.OwnerKeyHash) == 1
+}
+
+// invite signs a token that admits its holder until expires. Tokens are
+// "<unix expiry>.<signature>"; they name no room, but the signature covers
+// the room ID so a token only works where it was issued.
+func (a *RoomAccess) invite(roomID string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + base64.RawURLEncoding.EncodeToString(a.sign(roomID, exp))
+}
+
+func (a *RoomAccess) validInvite(roomID, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !now.Before(time.Unix(unix, 0)) {
+		return false
+	}
+	given, err := base64.RawURLEncoding.DecodeString(sig)
+	return err == nil && hmac.Equal(given, a.sign(roomID, exp))
+}
+
+func (a *RoomAccess) sign(roomID, exp string) []byte {
+	mac := hmac.New(sha256.New, a.InviteSecret)
+	// Room IDs never contain a dot, see roomIDPattern
+	mac.Write([]byte(roomID + "." + exp))
+	return mac.Sum(nil)
+}
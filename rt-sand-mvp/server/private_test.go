@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestInviteTokens(t *testing.T) {
+	access, owner, err := newRoomAccess("")
+	if err != nil {
+		t.Fatalf("new access: %v", err)
+	}
+	if !access.isOwner(owner) || access.isOwner(newSessionToken()) {
+		t.Fatalf("owner key not recognised")
+	}
+	now := time.Now()
+	token := access.invite("den", now.Add(time.Hour))
+	if !access.admits("den", "", token, now) {
+		t.Fatalf("fresh invite rejected")
+	}
+	if access.admits("den", "", token, now.Add(2*time.Hour)) {
+		t.Fatalf("expired invite accepted")
+	}
+	if access.admits("other", "", token, now) {
+		t.Fatalf("invite accepted for another room")
+	}
+	if access.admits("den", "guess", "", now) {
+		t.Fatalf("invite-only room accepted a password")
+	}
+}
+
+func TestPrivateRoom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryStore()
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/ws":
+			ServeWS(rm, w, r)
+		case r.URL.Path == "/api/rooms":
+			ServeRooms(rm, w, r)
+		default:
+			ServeRoomAPI(rm, w, r)
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/rooms", "application/json", strings.NewReader(`{"id":"den","password":"hunter2"}`))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %v %v", resp, err)
+	}
+	var created CreatedRoom
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if !created.Private || created.OwnerKey == "" {
+		t.Fatalf("expected a private room with an owner key, got %+v", created)
+	}
+	if infos := rm.GetRoomInfoList(); len(infos) != 0 {
+		t.Fatalf("private room is listed: %+v", infos)
+	}
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=den"
+	for _, query := range []string{"", "&password=wrong", "&invite=1.abc"} {
+		if _, resp, err := websocket.DefaultDialer.Dial(url+query, nil); err == nil || resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("%q: expected 403, got %v", query, err)
+		}
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"&password=hunter2", nil)
+	if err != nil {
+		t.Fatalf("dial with password: %v", err)
+	}
+	conn.Close()
+
+	// Only the owner can make invites
+	invite := func(key string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/rooms/den/invites", strings.NewReader(`{"ttl_seconds":60}`))
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("invite: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	if resp := invite("nope"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without the owner key, got %d", resp.StatusCode)
+	}
+	resp = invite(created.OwnerKey)
+	var inv Invite
+	if err := json.NewDecoder(resp.Body).Decode(&inv); err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("invite: %d %v", resp.StatusCode, err)
+	}
+	if !strings.Contains(inv.Link, "invite=") {
+		t.Fatalf("unexpected link %q", inv.Link)
+	}
+	conn, _, err = websocket.DefaultDialer.Dial(url+"&invite="+inv.Invite, nil)
+	if err != nil {
+		t.Fatalf("dial with invite: %v", err)
+	}
+	conn.Close()
+
+	// The REST endpoints are guarded the same way
+	if resp, _ := http.Get(srv.URL + "/api/rooms/den/leaderboard"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for the leaderboard, got %d", resp.StatusCode)
+	}
+	if resp, _ := http.Get(srv.URL + "/api/rooms/den/leaderboard?invite=" + inv.Invite); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with an invite, got %d", resp.StatusCode)
+	}
+	// but take no password; it buys an access token instead
+	if resp, _ := http.Get(srv.URL + "/api/rooms/den/leaderboard?password=hunter2"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for a password in the URL, got %d", resp.StatusCode)
+	}
+	access := func(password string) *http.Response {
+		resp, err := http.Post(srv.URL+"/api/rooms/den/access", "application/json", strings.NewReader(`{"password":"`+password+`"}`))
+		if err != nil {
+			t.Fatalf("access: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	if resp := access("wrong"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for a wrong password, got %d", resp.StatusCode)
+	}
+	var token AccessToken
+	if resp := access("hunter2"); resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&token) != nil {
+		t.Fatalf("access: %d", resp.StatusCode)
+	}
+	if resp, _ := http.Get(srv.URL + "/api/rooms/den/leaderboard?invite=" + token.Access); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with an access token, got %d", resp.StatusCode)
+	}
+	conn, _, err = websocket.DefaultDialer.Dial(url+"&invite="+token.Access, nil)
+	if err != nil {
+		t.Fatalf("dial with access token: %v", err)
+	}
+	conn.Close()
+
+	// Access is saved with the room
+	if rec, err := store.LoadRoom("den"); err != nil || rec.Access == nil || !rec.Access.Private {
+		t.Fatalf("access not saved: %+v %v", rec, err)
+	}
+}
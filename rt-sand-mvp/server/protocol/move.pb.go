@@ -0,0 +1,1977 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.25.3
+// source: move.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Cell struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X     int64 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y     int64 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Color int32 `protobuf:"varint,3,opt,name=color,proto3" json:"color,omitempty"` // 0-254, see Color in chunkstore.go
+}
+
+func (x *Cell) Reset() {
+	*x = Cell{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Cell) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cell) ProtoMessage() {}
+
+func (x *Cell) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cell.ProtoReflect.Descriptor instead.
+func (*Cell) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Cell) GetX() int64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Cell) GetY() int64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Cell) GetColor() int32 {
+	if x != nil {
+		return x.Color
+	}
+	return 0
+}
+
+type ChunkID struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X int32 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y int32 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+}
+
+func (x *ChunkID) Reset() {
+	*x = ChunkID{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChunkID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkID) ProtoMessage() {}
+
+func (x *ChunkID) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkID.ProtoReflect.Descriptor instead.
+func (*ChunkID) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChunkID) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *ChunkID) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type MoveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X     int64 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y     int64 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Color int32 `protobuf:"varint,3,opt,name=color,proto3" json:"color,omitempty"`
+}
+
+func (x *MoveRequest) Reset() {
+	*x = MoveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveRequest) ProtoMessage() {}
+
+func (x *MoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveRequest.ProtoReflect.Descriptor instead.
+func (*MoveRequest) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MoveRequest) GetX() int64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MoveRequest) GetY() int64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *MoveRequest) GetColor() int32 {
+	if x != nil {
+		return x.Color
+	}
+	return 0
+}
+
+type MoveResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Accepted     bool    `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Reason       string  `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Removed      []*Cell `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`
+	Added        *Cell   `protobuf:"bytes,4,opt,name=added,proto3" json:"added,omitempty"`
+	ServerSeq    uint64  `protobuf:"varint,5,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+	RetryAfterMs int64   `protobuf:"varint,6,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"` // with reason "cooldown"
+	FreeColors   []int32 `protobuf:"varint,7,rep,packed,name=free_colors,json=freeColors,proto3" json:"free_colors,omitempty"`  // with reason "color_taken"
+}
+
+func (x *MoveResult) Reset() {
+	*x = MoveResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MoveResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveResult) ProtoMessage() {}
+
+func (x *MoveResult) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveResult.ProtoReflect.Descriptor instead.
+func (*MoveResult) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MoveResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *MoveResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *MoveResult) GetRemoved() []*Cell {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *MoveResult) GetAdded() *Cell {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *MoveResult) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+func (x *MoveResult) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+func (x *MoveResult) GetFreeColors() []int32 {
+	if x != nil {
+		return x.FreeColors
+	}
+	return nil
+}
+
+type DeltaUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Added     []*Cell `protobuf:"bytes,1,rep,name=added,proto3" json:"added,omitempty"`
+	Removed   []*Cell `protobuf:"bytes,2,rep,name=removed,proto3" json:"removed,omitempty"`
+	ServerSeq uint64  `protobuf:"varint,3,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+}
+
+func (x *DeltaUpdate) Reset() {
+	*x = DeltaUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeltaUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaUpdate) ProtoMessage() {}
+
+func (x *DeltaUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeltaUpdate.ProtoReflect.Descriptor instead.
+func (*DeltaUpdate) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeltaUpdate) GetAdded() []*Cell {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *DeltaUpdate) GetRemoved() []*Cell {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *DeltaUpdate) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+// PackedChunk holds a chunk's cells in the run-length format described in
+// server/packed.go.
+type PackedChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X    int32  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y    int32  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *PackedChunk) Reset() {
+	*x = PackedChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PackedChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackedChunk) ProtoMessage() {}
+
+func (x *PackedChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackedChunk.ProtoReflect.Descriptor instead.
+func (*PackedChunk) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PackedChunk) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *PackedChunk) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *PackedChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type BoardState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cells     []*Cell        `protobuf:"bytes,1,rep,name=cells,proto3" json:"cells,omitempty"`
+	Chunks    []*ChunkID     `protobuf:"bytes,2,rep,name=chunks,proto3" json:"chunks,omitempty"` // set when the state only covers these chunks
+	ServerSeq uint64         `protobuf:"varint,3,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+	Packed    []*PackedChunk `protobuf:"bytes,4,rep,name=packed,proto3" json:"packed,omitempty"` // the server sends stones here, not in cells
+}
+
+func (x *BoardState) Reset() {
+	*x = BoardState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BoardState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoardState) ProtoMessage() {}
+
+func (x *BoardState) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoardState.ProtoReflect.Descriptor instead.
+func (*BoardState) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BoardState) GetCells() []*Cell {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+func (x *BoardState) GetChunks() []*ChunkID {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *BoardState) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+func (x *BoardState) GetPacked() []*PackedChunk {
+	if x != nil {
+		return x.Packed
+	}
+	return nil
+}
+
+type ChunkState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X         int32   `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y         int32   `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Version   uint64  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Cells     []*Cell `protobuf:"bytes,4,rep,name=cells,proto3" json:"cells,omitempty"`
+	ServerSeq uint64  `protobuf:"varint,5,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+}
+
+func (x *ChunkState) Reset() {
+	*x = ChunkState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChunkState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkState) ProtoMessage() {}
+
+func (x *ChunkState) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkState.ProtoReflect.Descriptor instead.
+func (*ChunkState) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChunkState) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *ChunkState) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *ChunkState) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ChunkState) GetCells() []*Cell {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+func (x *ChunkState) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+type Alliance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Colors []int32 `protobuf:"varint,1,rep,packed,name=colors,proto3" json:"colors,omitempty"`
+}
+
+func (x *Alliance) Reset() {
+	*x = Alliance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Alliance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alliance) ProtoMessage() {}
+
+func (x *Alliance) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alliance.ProtoReflect.Descriptor instead.
+func (*Alliance) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Alliance) GetColors() []int32 {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+// RuleSet mirrors server/rules.go; ko is "none", "simple" or "superko".
+type RuleSet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ko               string      `protobuf:"bytes,1,opt,name=ko,proto3" json:"ko,omitempty"`
+	ForbidSuicide    bool        `protobuf:"varint,2,opt,name=forbid_suicide,json=forbidSuicide,proto3" json:"forbid_suicide,omitempty"`
+	SelfCaptureFirst bool        `protobuf:"varint,3,opt,name=self_capture_first,json=selfCaptureFirst,proto3" json:"self_capture_first,omitempty"`
+	Alliances        []*Alliance `protobuf:"bytes,4,rep,name=alliances,proto3" json:"alliances,omitempty"`
+	Teams            int32       `protobuf:"varint,5,opt,name=teams,proto3" json:"teams,omitempty"`
+	TickMs           int32       `protobuf:"varint,6,opt,name=tick_ms,json=tickMs,proto3" json:"tick_ms,omitempty"`
+}
+
+func (x *RuleSet) Reset() {
+	*x = RuleSet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RuleSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleSet) ProtoMessage() {}
+
+func (x *RuleSet) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleSet.ProtoReflect.Descriptor instead.
+func (*RuleSet) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RuleSet) GetKo() string {
+	if x != nil {
+		return x.Ko
+	}
+	return ""
+}
+
+func (x *RuleSet) GetForbidSuicide() bool {
+	if x != nil {
+		return x.ForbidSuicide
+	}
+	return false
+}
+
+func (x *RuleSet) GetSelfCaptureFirst() bool {
+	if x != nil {
+		return x.SelfCaptureFirst
+	}
+	return false
+}
+
+func (x *RuleSet) GetAlliances() []*Alliance {
+	if x != nil {
+		return x.Alliances
+	}
+	return nil
+}
+
+func (x *RuleSet) GetTeams() int32 {
+	if x != nil {
+		return x.Teams
+	}
+	return 0
+}
+
+func (x *RuleSet) GetTickMs() int32 {
+	if x != nil {
+		return x.TickMs
+	}
+	return 0
+}
+
+type RoomInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id             string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	PlayerCount    int32    `protobuf:"varint,2,opt,name=player_count,json=playerCount,proto3" json:"player_count,omitempty"`
+	Rules          *RuleSet `protobuf:"bytes,3,opt,name=rules,proto3" json:"rules,omitempty"`
+	TakenColors    []int32  `protobuf:"varint,4,rep,packed,name=taken_colors,json=takenColors,proto3" json:"taken_colors,omitempty"`
+	SpectatorCount int32    `protobuf:"varint,5,opt,name=spectator_count,json=spectatorCount,proto3" json:"spectator_count,omitempty"`
+	MaxPlayers     int32    `protobuf:"varint,6,opt,name=max_players,json=maxPlayers,proto3" json:"max_players,omitempty"`
+	DisplayName    string   `protobuf:"bytes,7,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Owner          string   `protobuf:"bytes,8,opt,name=owner,proto3" json:"owner,omitempty"`
+}
+
+func (x *RoomInfo) Reset() {
+	*x = RoomInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RoomInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomInfo) ProtoMessage() {}
+
+func (x *RoomInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomInfo.ProtoReflect.Descriptor instead.
+func (*RoomInfo) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RoomInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetPlayerCount() int32 {
+	if x != nil {
+		return x.PlayerCount
+	}
+	return 0
+}
+
+func (x *RoomInfo) GetRules() *RuleSet {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *RoomInfo) GetTakenColors() []int32 {
+	if x != nil {
+		return x.TakenColors
+	}
+	return nil
+}
+
+func (x *RoomInfo) GetSpectatorCount() int32 {
+	if x != nil {
+		return x.SpectatorCount
+	}
+	return 0
+}
+
+func (x *RoomInfo) GetMaxPlayers() int32 {
+	if x != nil {
+		return x.MaxPlayers
+	}
+	return 0
+}
+
+func (x *RoomInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type Rect struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MinX int64 `protobuf:"varint,1,opt,name=min_x,json=minX,proto3" json:"min_x,omitempty"`
+	MinY int64 `protobuf:"varint,2,opt,name=min_y,json=minY,proto3" json:"min_y,omitempty"`
+	MaxX int64 `protobuf:"varint,3,opt,name=max_x,json=maxX,proto3" json:"max_x,omitempty"`
+	MaxY int64 `protobuf:"varint,4,opt,name=max_y,json=maxY,proto3" json:"max_y,omitempty"`
+}
+
+func (x *Rect) Reset() {
+	*x = Rect{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rect) ProtoMessage() {}
+
+func (x *Rect) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rect.ProtoReflect.Descriptor instead.
+func (*Rect) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Rect) GetMinX() int64 {
+	if x != nil {
+		return x.MinX
+	}
+	return 0
+}
+
+func (x *Rect) GetMinY() int64 {
+	if x != nil {
+		return x.MinY
+	}
+	return 0
+}
+
+func (x *Rect) GetMaxX() int64 {
+	if x != nil {
+		return x.MaxX
+	}
+	return 0
+}
+
+func (x *Rect) GetMaxY() int64 {
+	if x != nil {
+		return x.MaxY
+	}
+	return 0
+}
+
+type ColorScore struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Color     int32 `protobuf:"varint,1,opt,name=color,proto3" json:"color,omitempty"`
+	Stones    int32 `protobuf:"varint,2,opt,name=stones,proto3" json:"stones,omitempty"`
+	Territory int32 `protobuf:"varint,3,opt,name=territory,proto3" json:"territory,omitempty"`
+	Score     int32 `protobuf:"varint,4,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *ColorScore) Reset() {
+	*x = ColorScore{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ColorScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColorScore) ProtoMessage() {}
+
+func (x *ColorScore) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColorScore.ProtoReflect.Descriptor instead.
+func (*ColorScore) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ColorScore) GetColor() int32 {
+	if x != nil {
+		return x.Color
+	}
+	return 0
+}
+
+func (x *ColorScore) GetStones() int32 {
+	if x != nil {
+		return x.Stones
+	}
+	return 0
+}
+
+func (x *ColorScore) GetTerritory() int32 {
+	if x != nil {
+		return x.Territory
+	}
+	return 0
+}
+
+func (x *ColorScore) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+// Score mirrors server/score.go.
+type Score struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Region    *Rect         `protobuf:"bytes,1,opt,name=region,proto3" json:"region,omitempty"`
+	Colors    []*ColorScore `protobuf:"bytes,2,rep,name=colors,proto3" json:"colors,omitempty"`
+	Neutral   int32         `protobuf:"varint,3,opt,name=neutral,proto3" json:"neutral,omitempty"`
+	ServerSeq uint64        `protobuf:"varint,4,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+}
+
+func (x *Score) Reset() {
+	*x = Score{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Score) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Score) ProtoMessage() {}
+
+func (x *Score) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Score.ProtoReflect.Descriptor instead.
+func (*Score) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Score) GetRegion() *Rect {
+	if x != nil {
+		return x.Region
+	}
+	return nil
+}
+
+func (x *Score) GetColors() []*ColorScore {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *Score) GetNeutral() int32 {
+	if x != nil {
+		return x.Neutral
+	}
+	return 0
+}
+
+func (x *Score) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+type ColorStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Color    int32 `protobuf:"varint,1,opt,name=color,proto3" json:"color,omitempty"`
+	Stones   int32 `protobuf:"varint,2,opt,name=stones,proto3" json:"stones,omitempty"`
+	Captured int32 `protobuf:"varint,3,opt,name=captured,proto3" json:"captured,omitempty"`
+	Lost     int32 `protobuf:"varint,4,opt,name=lost,proto3" json:"lost,omitempty"`
+	Moves    int32 `protobuf:"varint,5,opt,name=moves,proto3" json:"moves,omitempty"`
+}
+
+func (x *ColorStats) Reset() {
+	*x = ColorStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ColorStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColorStats) ProtoMessage() {}
+
+func (x *ColorStats) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColorStats.ProtoReflect.Descriptor instead.
+func (*ColorStats) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ColorStats) GetColor() int32 {
+	if x != nil {
+		return x.Color
+	}
+	return 0
+}
+
+func (x *ColorStats) GetStones() int32 {
+	if x != nil {
+		return x.Stones
+	}
+	return 0
+}
+
+func (x *ColorStats) GetCaptured() int32 {
+	if x != nil {
+		return x.Captured
+	}
+	return 0
+}
+
+func (x *ColorStats) GetLost() int32 {
+	if x != nil {
+		return x.Lost
+	}
+	return 0
+}
+
+func (x *ColorStats) GetMoves() int32 {
+	if x != nil {
+		return x.Moves
+	}
+	return 0
+}
+
+// Leaderboard mirrors server/leaderboard.go.
+type Leaderboard struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Colors    []*ColorStats `protobuf:"bytes,1,rep,name=colors,proto3" json:"colors,omitempty"`
+	ServerSeq uint64        `protobuf:"varint,2,opt,name=server_seq,json=serverSeq,proto3" json:"server_seq,omitempty"`
+}
+
+func (x *Leaderboard) Reset() {
+	*x = Leaderboard{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Leaderboard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Leaderboard) ProtoMessage() {}
+
+func (x *Leaderboard) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Leaderboard.ProtoReflect.Descriptor instead.
+func (*Leaderboard) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Leaderboard) GetColors() []*ColorStats {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *Leaderboard) GetServerSeq() uint64 {
+	if x != nil {
+		return x.ServerSeq
+	}
+	return 0
+}
+
+// Session mirrors server/session.go; color and stats are only set when the
+// session holds a color.
+type Session struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token string      `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Color *int32      `protobuf:"varint,2,opt,name=color,proto3,oneof" json:"color,omitempty"`
+	Stats *ColorStats `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Session) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Session) GetColor() int32 {
+	if x != nil && x.Color != nil {
+		return *x.Color
+	}
+	return 0
+}
+
+func (x *Session) GetStats() *ColorStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// Envelope is every server-to-client message: move_result (also used for
+// errors and color_selected), delta_update, board_state, chunk_state,
+// room_info, score, leaderboard and session.
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type        string       `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	MoveResult  *MoveResult  `protobuf:"bytes,2,opt,name=move_result,json=moveResult,proto3" json:"move_result,omitempty"`
+	DeltaUpdate *DeltaUpdate `protobuf:"bytes,3,opt,name=delta_update,json=deltaUpdate,proto3" json:"delta_update,omitempty"`
+	BoardState  *BoardState  `protobuf:"bytes,4,opt,name=board_state,json=boardState,proto3" json:"board_state,omitempty"`
+	ChunkState  *ChunkState  `protobuf:"bytes,5,opt,name=chunk_state,json=chunkState,proto3" json:"chunk_state,omitempty"`
+	RoomInfo    *RoomInfo    `protobuf:"bytes,6,opt,name=room_info,json=roomInfo,proto3" json:"room_info,omitempty"`
+	Score       *Score       `protobuf:"bytes,7,opt,name=score,proto3" json:"score,omitempty"`
+	Leaderboard *Leaderboard `protobuf:"bytes,8,opt,name=leaderboard,proto3" json:"leaderboard,omitempty"`
+	Session     *Session     `protobuf:"bytes,9,opt,name=session,proto3" json:"session,omitempty"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Envelope) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Envelope) GetMoveResult() *MoveResult {
+	if x != nil {
+		return x.MoveResult
+	}
+	return nil
+}
+
+func (x *Envelope) GetDeltaUpdate() *DeltaUpdate {
+	if x != nil {
+		return x.DeltaUpdate
+	}
+	return nil
+}
+
+func (x *Envelope) GetBoardState() *BoardState {
+	if x != nil {
+		return x.BoardState
+	}
+	return nil
+}
+
+func (x *Envelope) GetChunkState() *ChunkState {
+	if x != nil {
+		return x.ChunkState
+	}
+	return nil
+}
+
+func (x *Envelope) GetRoomInfo() *RoomInfo {
+	if x != nil {
+		return x.RoomInfo
+	}
+	return nil
+}
+
+func (x *Envelope) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+func (x *Envelope) GetLeaderboard() *Leaderboard {
+	if x != nil {
+		return x.Leaderboard
+	}
+	return nil
+}
+
+func (x *Envelope) GetSession() *Session {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+// ClientMessage is every client-to-server message. An empty type is a move.
+// get_score leaves the rectangle unset to score the whole board.
+type ClientMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type   string     `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	X      int64      `protobuf:"varint,2,opt,name=x,proto3" json:"x,omitempty"`
+	Y      int64      `protobuf:"varint,3,opt,name=y,proto3" json:"y,omitempty"`
+	Color  int32      `protobuf:"varint,4,opt,name=color,proto3" json:"color,omitempty"`
+	MinX   *int64     `protobuf:"varint,5,opt,name=min_x,json=minX,proto3,oneof" json:"min_x,omitempty"`
+	MinY   *int64     `protobuf:"varint,6,opt,name=min_y,json=minY,proto3,oneof" json:"min_y,omitempty"`
+	MaxX   *int64     `protobuf:"varint,7,opt,name=max_x,json=maxX,proto3,oneof" json:"max_x,omitempty"`
+	MaxY   *int64     `protobuf:"varint,8,opt,name=max_y,json=maxY,proto3,oneof" json:"max_y,omitempty"`
+	Chunks []*ChunkID `protobuf:"bytes,9,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	Speed  float64    `protobuf:"fixed64,10,opt,name=speed,proto3" json:"speed,omitempty"`
+}
+
+func (x *ClientMessage) Reset() {
+	*x = ClientMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_move_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientMessage) ProtoMessage() {}
+
+func (x *ClientMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_move_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientMessage.ProtoReflect.Descriptor instead.
+func (*ClientMessage) Descriptor() ([]byte, []int) {
+	return file_move_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ClientMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ClientMessage) GetX() int64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetY() int64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetColor() int32 {
+	if x != nil {
+		return x.Color
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetMinX() int64 {
+	if x != nil && x.MinX != nil {
+		return *x.MinX
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetMinY() int64 {
+	if x != nil && x.MinY != nil {
+		return *x.MinY
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetMaxX() int64 {
+	if x != nil && x.MaxX != nil {
+		return *x.MaxX
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetMaxY() int64 {
+	if x != nil && x.MaxY != nil {
+		return *x.MaxY
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetChunks() []*ChunkID {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+var File_move_proto protoreflect.FileDescriptor
+
+var file_move_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x6d, 0x6f, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x72, 0x74,
+	0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x22, 0x38, 0x0a, 0x04, 0x43, 0x65, 0x6c, 0x6c, 0x12,
+	0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a,
+	0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x22, 0x25, 0x0a, 0x07, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x49, 0x44, 0x12, 0x0c, 0x0a, 0x01,
+	0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x22, 0x3f, 0x0a, 0x0b, 0x4d, 0x6f, 0x76, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0xf8, 0x01, 0x0a, 0x0a, 0x4d, 0x6f,
+	0x76, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x07,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
+	0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x65, 0x6c, 0x6c, 0x52, 0x07,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d,
+	0x76, 0x70, 0x2e, 0x43, 0x65, 0x6c, 0x6c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x71, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x65, 0x71, 0x12, 0x24, 0x0a,
+	0x0e, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x6d, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65,
+	0x72, 0x4d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x72, 0x65, 0x65, 0x5f, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0a, 0x66, 0x72, 0x65, 0x65, 0x43, 0x6f,
+	0x6c, 0x6f, 0x72, 0x73, 0x22, 0x7e, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x12, 0x25, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43,
+	0x65, 0x6c, 0x6c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x29, 0x0a, 0x07, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x74,
+	0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x65, 0x6c, 0x6c, 0x52, 0x07, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f,
+	0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x53, 0x65, 0x71, 0x22, 0x3d, 0x0a, 0x0b, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01,
+	0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x22, 0xae, 0x01, 0x0a, 0x0a, 0x42, 0x6f, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x65,
+	0x6c, 0x6c, 0x52, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x12, 0x2a, 0x0a, 0x06, 0x63, 0x68, 0x75,
+	0x6e, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x74, 0x73, 0x61,
+	0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x49, 0x44, 0x52, 0x06, 0x63,
+	0x68, 0x75, 0x6e, 0x6b, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f,
+	0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x53, 0x65, 0x71, 0x12, 0x2e, 0x0a, 0x06, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70,
+	0x2e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x52, 0x06, 0x70, 0x61,
+	0x63, 0x6b, 0x65, 0x64, 0x22, 0x88, 0x01, 0x0a, 0x0a, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01,
+	0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x65, 0x6c,
+	0x6c, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e,
+	0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x65, 0x6c, 0x6c, 0x52, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x71, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x65, 0x71, 0x22,
+	0x22, 0x0a, 0x08, 0x41, 0x6c, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x06, 0x63, 0x6f, 0x6c,
+	0x6f, 0x72, 0x73, 0x22, 0xd0, 0x01, 0x0a, 0x07, 0x52, 0x75, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x6b, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6b, 0x6f, 0x12,
+	0x25, 0x0a, 0x0e, 0x66, 0x6f, 0x72, 0x62, 0x69, 0x64, 0x5f, 0x73, 0x75, 0x69, 0x63, 0x69, 0x64,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x66, 0x6f, 0x72, 0x62, 0x69, 0x64, 0x53,
+	0x75, 0x69, 0x63, 0x69, 0x64, 0x65, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x65, 0x6c, 0x66, 0x5f, 0x63,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x10, 0x73, 0x65, 0x6c, 0x66, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x46,
+	0x69, 0x72, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x09, 0x61, 0x6c, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64,
+	0x6d, 0x76, 0x70, 0x2e, 0x41, 0x6c, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x09, 0x61, 0x6c,
+	0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x65, 0x61, 0x6d, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x74, 0x65, 0x61, 0x6d, 0x73, 0x12, 0x17, 0x0a,
+	0x07, 0x74, 0x69, 0x63, 0x6b, 0x5f, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x74, 0x69, 0x63, 0x6b, 0x4d, 0x73, 0x22, 0x8d, 0x02, 0x0a, 0x08, 0x52, 0x6f, 0x6f, 0x6d, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76,
+	0x70, 0x2e, 0x52, 0x75, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73,
+	0x12, 0x21, 0x0a, 0x0c, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0b, 0x74, 0x61, 0x6b, 0x65, 0x6e, 0x43, 0x6f, 0x6c,
+	0x6f, 0x72, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x70, 0x65, 0x63, 0x74, 0x61, 0x74, 0x6f, 0x72,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x73, 0x70,
+	0x65, 0x63, 0x74, 0x61, 0x74, 0x6f, 0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b,
+	0x6d, 0x61, 0x78, 0x5f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x22, 0x5a, 0x0a, 0x04, 0x52, 0x65, 0x63, 0x74, 0x12, 0x13,
+	0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d,
+	0x69, 0x6e, 0x58, 0x12, 0x13, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x59, 0x12, 0x13, 0x0a, 0x05, 0x6d, 0x61, 0x78, 0x5f,
+	0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x61, 0x78, 0x58, 0x12, 0x13, 0x0a,
+	0x05, 0x6d, 0x61, 0x78, 0x5f, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x61,
+	0x78, 0x59, 0x22, 0x6e, 0x0a, 0x0a, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x53, 0x63, 0x6f, 0x72, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x65, 0x72, 0x72, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x09, 0x74, 0x65, 0x72, 0x72, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x63, 0x6f,
+	0x72, 0x65, 0x22, 0x98, 0x01, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x27, 0x0a, 0x06,
+	0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72,
+	0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x52, 0x65, 0x63, 0x74, 0x52, 0x06, 0x72,
+	0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x2d, 0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76,
+	0x70, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x06, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6e, 0x65, 0x75, 0x74, 0x72, 0x61, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6e, 0x65, 0x75, 0x74, 0x72, 0x61, 0x6c, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x71, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x65, 0x71, 0x22, 0x80, 0x01,
+	0x0a, 0x0a, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c,
+	0x6f, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x06, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x73, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6c, 0x6f, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f,
+	0x76, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6d, 0x6f, 0x76, 0x65, 0x73,
+	0x22, 0x5b, 0x0a, 0x0b, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12,
+	0x2d, 0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x6f, 0x6c, 0x6f,
+	0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x65, 0x71, 0x22, 0x71, 0x0a,
+	0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x19,
+	0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52,
+	0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e,
+	0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72,
+	0x22, 0xc3, 0x03, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x12, 0x36, 0x0a, 0x0b, 0x6d, 0x6f, 0x76, 0x65, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d,
+	0x76, 0x70, 0x2e, 0x4d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x0a, 0x6d,
+	0x6f, 0x76, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x39, 0x0a, 0x0c, 0x64, 0x65, 0x6c,
+	0x74, 0x61, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x44, 0x65, 0x6c, 0x74,
+	0x61, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x0b, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x12, 0x36, 0x0a, 0x0b, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x5f, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x74, 0x73, 0x61,
+	0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x42, 0x6f, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x52, 0x0a, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x36, 0x0a, 0x0b,
+	0x63, 0x68, 0x75, 0x6e, 0x6b, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x0a, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d, 0x5f, 0x69, 0x6e, 0x66,
+	0x6f, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64,
+	0x6d, 0x76, 0x70, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x72, 0x6f,
+	0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76,
+	0x70, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x38,
+	0x0a, 0x0b, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e,
+	0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x52, 0x0b, 0x6c, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12, 0x2c, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x74, 0x73, 0x61,
+	0x6e, 0x64, 0x6d, 0x76, 0x70, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xa7, 0x02, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x01,
+	0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x18,
+	0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52,
+	0x04, 0x6d, 0x69, 0x6e, 0x58, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f,
+	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x48, 0x01, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x59, 0x88,
+	0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x61, 0x78, 0x5f, 0x78, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x03, 0x48, 0x02, 0x52, 0x04, 0x6d, 0x61, 0x78, 0x58, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05,
+	0x6d, 0x61, 0x78, 0x5f, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x03, 0x52, 0x04, 0x6d,
+	0x61, 0x78, 0x59, 0x88, 0x01, 0x01, 0x12, 0x2a, 0x0a, 0x06, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x73,
+	0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x74, 0x73, 0x61, 0x6e, 0x64, 0x6d,
+	0x76, 0x70, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x49, 0x44, 0x52, 0x06, 0x63, 0x68, 0x75, 0x6e,
+	0x6b, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x70, 0x65, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x73, 0x70, 0x65, 0x65, 0x64, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x69, 0x6e,
+	0x5f, 0x78, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x79, 0x42, 0x08, 0x0a, 0x06,
+	0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x78, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x79,
+	0x42, 0x47, 0x5a, 0x45, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x41,
+	0x6e, 0x74, 0x68, 0x6f, 0x6e, 0x79, 0x2d, 0x70, 0x69, 0x2d, 0x46, 0x72, 0x61, 0x6e, 0x6b, 0x6c,
+	0x69, 0x6e, 0x2f, 0x49, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x65, 0x47, 0x6f, 0x2f, 0x72, 0x74,
+	0x2d, 0x73, 0x61, 0x6e, 0x64, 0x2d, 0x6d, 0x76, 0x70, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_move_proto_rawDescOnce sync.Once
+	file_move_proto_rawDescData = file_move_proto_rawDesc
+)
+
+func file_move_proto_rawDescGZIP() []byte {
+	file_move_proto_rawDescOnce.Do(func() {
+		file_move_proto_rawDescData = protoimpl.X.CompressGZIP(file_move_proto_rawDescData)
+	})
+	return file_move_proto_rawDescData
+}
+
+var file_move_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_move_proto_goTypes = []any{
+	(*Cell)(nil),          // 0: rtsandmvp.Cell
+	(*ChunkID)(nil),       // 1: rtsandmvp.ChunkID
+	(*MoveRequest)(nil),   // 2: rtsandmvp.MoveRequest
+	(*MoveResult)(nil),    // 3: rtsandmvp.MoveResult
+	(*DeltaUpdate)(nil),   // 4: rtsandmvp.DeltaUpdate
+	(*PackedChunk)(nil),   // 5: rtsandmvp.PackedChunk
+	(*BoardState)(nil),    // 6: rtsandmvp.BoardState
+	(*ChunkState)(nil),    // 7: rtsandmvp.ChunkState
+	(*Alliance)(nil),      // 8: rtsandmvp.Alliance
+	(*RuleSet)(nil),       // 9: rtsandmvp.RuleSet
+	(*RoomInfo)(nil),      // 10: rtsandmvp.RoomInfo
+	(*Rect)(nil),          // 11: rtsandmvp.Rect
+	(*ColorScore)(nil),    // 12: rtsandmvp.ColorScore
+	(*Score)(nil),         // 13: rtsandmvp.Score
+	(*ColorStats)(nil),    // 14: rtsandmvp.ColorStats
+	(*Leaderboard)(nil),   // 15: rtsandmvp.Leaderboard
+	(*Session)(nil),       // 16: rtsandmvp.Session
+	(*Envelope)(nil),      // 17: rtsandmvp.Envelope
+	(*ClientMessage)(nil), // 18: rtsandmvp.ClientMessage
+}
+var file_move_proto_depIdxs = []int32{
+	0,  // 0: rtsandmvp.MoveResult.removed:type_name -> rtsandmvp.Cell
+	0,  // 1: rtsandmvp.MoveResult.added:type_name -> rtsandmvp.Cell
+	0,  // 2: rtsandmvp.DeltaUpdate.added:type_name -> rtsandmvp.Cell
+	0,  // 3: rtsandmvp.DeltaUpdate.removed:type_name -> rtsandmvp.Cell
+	0,  // 4: rtsandmvp.BoardState.cells:type_name -> rtsandmvp.Cell
+	1,  // 5: rtsandmvp.BoardState.chunks:type_name -> rtsandmvp.ChunkID
+	5,  // 6: rtsandmvp.BoardState.packed:type_name -> rtsandmvp.PackedChunk
+	0,  // 7: rtsandmvp.ChunkState.cells:type_name -> rtsandmvp.Cell
+	8,  // 8: rtsandmvp.RuleSet.alliances:type_name -> rtsandmvp.Alliance
+	9,  // 9: rtsandmvp.RoomInfo.rules:type_name -> rtsandmvp.RuleSet
+	11, // 10: rtsandmvp.Score.region:type_name -> rtsandmvp.Rect
+	12, // 11: rtsandmvp.Score.colors:type_name -> rtsandmvp.ColorScore
+	14, // 12: rtsandmvp.Leaderboard.colors:type_name -> rtsandmvp.ColorStats
+	14, // 13: rtsandmvp.Session.stats:type_name -> rtsandmvp.ColorStats
+	3,  // 14: rtsandmvp.Envelope.move_result:type_name -> rtsandmvp.MoveResult
+	4,  // 15: rtsandmvp.Envelope.delta_update:type_name -> rtsandmvp.DeltaUpdate
+	6,  // 16: rtsandmvp.Envelope.board_state:type_name -> rtsandmvp.BoardState
+	7,  // 17: rtsandmvp.Envelope.chunk_state:type_name -> rtsandmvp.ChunkState
+	10, // 18: rtsandmvp.Envelope.room_info:type_name -> rtsandmvp.RoomInfo
+	13, // 19: rtsandmvp.Envelope.score:type_name -> rtsandmvp.Score
+	15, // 20: rtsandmvp.Envelope.leaderboard:type_name -> rtsandmvp.Leaderboard
+	16, // 21: rtsandmvp.Envelope.session:type_name -> rtsandmvp.Session
+	1,  // 22: rtsandmvp.ClientMessage.chunks:type_name -> rtsandmvp.ChunkID
+	23, // [23:23] is the sub-list for method output_type
+	23, // [23:23] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_move_proto_init() }
+func file_move_proto_init() {
+	if File_move_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_move_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Cell); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ChunkID); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*MoveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*MoveResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*DeltaUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*PackedChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*BoardState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*ChunkState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Alliance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*RuleSet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*RoomInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*Rect); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*ColorScore); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*Score); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*ColorStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*Leaderboard); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*Session); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_move_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*ClientMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_move_proto_msgTypes[16].OneofWrappers = []any{}
+	file_move_proto_msgTypes[18].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_move_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_move_proto_goTypes,
+		DependencyIndexes: file_move_proto_depIdxs,
+		MessageInfos:      file_move_proto_msgTypes,
+	}.Build()
+	File_move_proto = out.File
+	file_move_proto_rawDesc = nil
+	file_move_proto_goTypes = nil
+	file_move_proto_depIdxs = nil
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket: Rate moves per second on average, with up
+// to Burst moves at once. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Defaults allow fast human play but stop scripts from flooding the board.
+// Several people sharing a color get a little more room than one.
+var (
+	DefaultClientLimit = RateLimit{Rate: 5, Burst: 10}
+	DefaultColorLimit  = RateLimit{Rate: 8, Burst: 16}
+)
+
+// tokenBucket is the state of one RateLimit; it is owned by the room
+// goroutine
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// wait refills the bucket up to now and returns how long until a token is
+// available, zero if one is available already
+func (b *tokenBucket) wait(limit RateLimit, now time.Time) time.Duration {
+	if limit.Rate <= 0 {
+		return 0
+	}
+	burst := math.Max(float64(limit.Burst), 1)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / limit.Rate * float64(time.Second)))
+}
+
+func (b *tokenBucket) take(limit RateLimit) {
+	if limit.Rate > 0 {
+		b.tokens--
+	}
+}
+
+// admitMove charges a move against the client's and the color's buckets.
+// It returns a cooldown result when either is empty, in which case neither
+// is charged.
+func (r *Room) admitMove(req MoveRequest, now time.Time) (MoveResult, bool) {
+	if req.Player == nil {
+		return MoveResult{}, true
+	}
+	if r.colorBuckets == nil {
+		r.colorBuckets = make(map[Color]*tokenBucket)
+	}
+	colorBucket, ok := r.colorBuckets[req.Color]
+	if !ok {
+		colorBucket = &tokenBucket{}
+		r.colorBuckets[req.Color] = colorBucket
+	}
+	clientBucket := &req.Player.bucket
+
+	wait := clientBucket.wait(r.Config.ClientLimit, now)
+	if w := colorBucket.wait(r.Config.ColorLimit, now); w > wait {
+		wait = w
+	}
+	if wait > 0 {
+		return MoveResult{
+			Accepted:   false,
+			Reason:     "cooldown",
+			RetryAfter: int64(math.Ceil(float64(wait) / float64(time.Millisecond))),
+			ServerSeq:  r.Seq,
+		}, false
+	}
+	clientBucket.take(r.Config.ClientLimit)
+	colorBucket.take(r.Config.ColorLimit)
+	return MoveResult{}, true
+}
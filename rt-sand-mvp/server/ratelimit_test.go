@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientRateLimit(t *testing.T) {
+	room := NewRoom()
+	room.Config.ClientLimit = RateLimit{Rate: 2, Burst: 3}
+	room.Config.ColorLimit = RateLimit{}
+	c := &Client{room: room}
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, ok := room.admitMove(MoveRequest{Player: c, Color: ColorRed}, now); !ok {
+			t.Fatalf("move %d within the burst was refused", i)
+		}
+	}
+	res, ok := room.admitMove(MoveRequest{Player: c, Color: ColorRed}, now)
+	if ok || res.Reason != "cooldown" || res.RetryAfter != 500 {
+		t.Fatalf("expected a 500ms cooldown, got %+v", res)
+	}
+	// Refused moves cost nothing, so the token arrives on schedule
+	if _, ok := room.admitMove(MoveRequest{Player: c, Color: ColorRed}, now.Add(500*time.Millisecond)); !ok {
+		t.Fatalf("move after the cooldown was refused")
+	}
+}
+
+func TestColorRateLimitIsShared(t *testing.T) {
+	room := NewRoom()
+	room.Config.ClientLimit = RateLimit{Rate: 10, Burst: 10}
+	room.Config.ColorLimit = RateLimit{Rate: 1, Burst: 2}
+	a, b := &Client{room: room}, &Client{room: room}
+	now := time.Unix(1000, 0)
+
+	room.admitMove(MoveRequest{Player: a, Color: ColorBlue}, now)
+	room.admitMove(MoveRequest{Player: b, Color: ColorBlue}, now)
+	if res, ok := room.admitMove(MoveRequest{Player: a, Color: ColorBlue}, now); ok || res.RetryAfter != 1000 {
+		t.Fatalf("two clients should share the color's bucket, got %+v", res)
+	}
+	if _, ok := room.admitMove(MoveRequest{Player: a, Color: ColorGreen}, now); !ok {
+		t.Fatalf("another color has its own bucket")
+	}
+	// Moves replayed from the journal have no player and are never limited
+	if _, ok := room.admitMove(MoveRequest{Color: ColorBlue}, now); !ok {
+		t.Fatalf("server moves should not be limited")
+	}
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Replay is the board as it was at From plus the deltas that lead from it
+// to To, one per journal entry
+type Replay struct {
+	From   uint64        `json:"from"`
+	To     uint64        `json:"to"`
+	Board  BoardState    `json:"board"`
+	Deltas []DeltaUpdate `json:"deltas"`
+}
+
+// Replay limits. Speeds are in steps per second; 0 pauses a replay.
+// MaxReplayRebuild caps the journal entries replayed between the nearest
+// snapshot and from.
+const (
+	MaxReplaySteps     = 10000
+	MaxReplayRebuild   = 100000
+	DefaultReplaySpeed = 10
+	MaxReplaySpeed     = 1000
+)
+
+// ErrReplayRange is returned for a from after to or after the room's latest
+// seq
+var ErrReplayRange = errors.New("invalid replay range")
+
+// ErrReplayTooFar is returned when from lies more than MaxReplayRebuild
+// seqs past the nearest snapshot
+var ErrReplayTooFar = errors.New("no snapshot close enough to replay from")
+
+// ReplayTooEarlyError is returned for a from before the room's recorded
+// history, as in rooms migrated from chunk-only storage
+type ReplayTooEarlyError struct {
+	Earliest uint64
+}
+
+func (e *ReplayTooEarlyError) Error() string {
+	return fmt.Sprintf("history starts at seq %d", e.Earliest)
+}
+
+// ErrReplayOnly is sent to replay connections that try anything but
+// changing the speed
+const ErrReplayOnly = "replay"
+
+// Replay rebuilds the board at seq from and collects the changes up to seq
+// to. The board at a seq includes every journal entry at or below it, so a
+// reset journaled at from is already applied. to is clamped to the latest
+// seq and to at most MaxReplaySteps after from; only the journal up to to
+// is read.
+//
+// The work happens on a scratch room, so Replay can be called from any
+// goroutine and never touches the live board.
+func (r *Room) Replay(from, to uint64) (*Replay, error) {
+	if r.store == nil {
+		return nil, ErrNoStore
+	}
+	if from > to {
+		return nil, ErrReplayRange
+	}
+	scratch := NewRoom()
+	scratch.ID = r.ID
+	scratch.Rules = r.Rules
+	scratch.Config = r.Config
+	snap, err := r.store.LoadSnapshotAt(r.ID, from)
+	switch {
+	case errors.Is(err, ErrSnapshotNotFound):
+		// The journal is never pruned, so an empty board is a valid base
+		// as long as the journal starts there
+		if err := r.journalFromEmpty(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if err := scratch.restoreSnapshot(snap); err != nil {
+			return nil, err
+		}
+	}
+	if from > scratch.Seq && from-scratch.Seq > MaxReplayRebuild {
+		return nil, ErrReplayTooFar
+	}
+	if to-from > MaxReplaySteps {
+		to = from + MaxReplaySteps
+	}
+	entries, err := r.store.LoadMoves(r.ID, scratch.Seq, to)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := scratch.Seq
+	if n := len(entries); n > 0 && entries[n-1].ServerSeq > latest {
+		latest = entries[n-1].ServerSeq
+	}
+	if from > latest {
+		return nil, ErrReplayRange
+	}
+	if to > latest {
+		to = latest
+	}
+	start := sort.Search(len(entries), func(i int) bool { return entries[i].ServerSeq > from })
+	end := sort.Search(len(entries), func(i int) bool { return entries[i].ServerSeq > to })
+
+	scratch.rehash()
+	scratch.resetKo()
+	scratch.recountStones()
+	if err := scratch.replay(entries[:start], nil); err != nil {
+		return nil, err
+	}
+	out := &Replay{From: from, To: to, Board: scratch.GetBoardState()}
+	emit := func(delta DeltaUpdate) { out.Deltas = append(out.Deltas, delta) }
+	if err := scratch.replay(entries[start:end], emit); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// journalFromEmpty checks that the journal reaches back to the empty board.
+// Rooms migrated from chunk-only storage have no such journal; their
+// history starts at their oldest snapshot.
+func (r *Room) journalFromEmpty() error {
+	first, err := r.store.LoadMoves(r.ID, 0, 1)
+	if err != nil || len(first) > 0 {
+		return err
+	}
+	snaps, err := r.store.ListSnapshots(r.ID)
+	if err != nil || len(snaps) == 0 {
+		// Nothing was ever played
+		return err
+	}
+	earliest := snaps[0].ServerSeq
+	for _, s := range snaps[1:] {
+		if s.ServerSeq < earliest {
+			earliest = s.ServerSeq
+		}
+	}
+	return &ReplayTooEarlyError{Earliest: earliest}
+}
+
+// replayQuery runs the replay asked for by the from and to query
+// parameters. A missing from starts at the earliest seq the room can
+// rebuild, which is the empty board unless its history was cut short.
+func (r *Room) replayQuery(q url.Values) (*Replay, error) {
+	from, to, err := parseReplayRange(q)
+	if err != nil {
+		return nil, err
+	}
+	replay, err := r.Replay(from, to)
+	var tooEarly *ReplayTooEarlyError
+	if q.Get("from") == "" && errors.As(err, &tooEarly) {
+		return r.Replay(tooEarly.Earliest, to)
+	}
+	return replay, err
+}
+
+// parseReplayRange reads the from and to query parameters. A missing from
+// is seq 0 and a missing to runs to the latest seq.
+func parseReplayRange(q url.Values) (from, to uint64, err error) {
+	to = math.MaxUint64
+	if s := q.Get("from"); s != "" {
+		if from, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return 0, 0, ErrReplayRange
+		}
+	}
+	if s := q.Get("to"); s != "" {
+		if to, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return 0, 0, ErrReplayRange
+		}
+	}
+	return from, to, nil
+}
+
+// parseReplaySpeed reads a speed in steps per second
+func parseReplaySpeed(s string) (float64, bool) {
+	if s == "" {
+		return DefaultReplaySpeed, true
+	}
+	speed, err := strconv.ParseFloat(s, 64)
+	return speed, err == nil && validReplaySpeed(speed)
+}
+
+func validReplaySpeed(speed float64) bool {
+	return speed >= 0 && speed <= MaxReplaySpeed
+}
+
+// replayInterval is the pause between steps at a non-zero speed
+func replayInterval(speed float64) time.Duration {
+	return time.Duration(float64(time.Second) / speed)
+}
+
+// playReplay streams a replay to a client that is not in the room: the
+// board at From, then one delta_update per step at the current speed, then
+// replay_done. Speed changes arrive on speeds. The connection stays open
+// until the client closes it.
+func (c *Client) playReplay(ctx context.Context, replay *Replay, speed float64, speeds <-chan float64) {
+	if !c.queue(ctx, Envelope{Type: "board_state", BoardState: &replay.Board}) {
+		return
+	}
+	ticker := time.NewTicker(replayInterval(DefaultReplaySpeed))
+	defer ticker.Stop()
+	if speed > 0 {
+		ticker.Reset(replayInterval(speed))
+	}
+	for i := 0; i < len(replay.Deltas); {
+		var tick <-chan time.Time
+		if speed > 0 {
+			tick = ticker.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case speed = <-speeds:
+			if speed > 0 {
+				ticker.Reset(replayInterval(speed))
+			}
+		case <-tick:
+			if !c.queue(ctx, Envelope{Type: "delta_update", DeltaUpdate: &replay.Deltas[i]}) {
+				return
+			}
+			i++
+		}
+	}
+	if !c.queue(ctx, Envelope{Type: "replay_done"}) {
+		return
+	}
+	// Keep taking speed changes so the read pump never blocks
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-speeds:
+		}
+	}
+}
+
+// replayReadPump reads a replay connection, which may only change the
+// speed
+func (c *Client) replayReadPump(ctx context.Context, cancel context.CancelFunc, speeds chan<- float64) {
+	defer func() {
+		cancel()
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(1 << 16)
+	for {
+		messageType, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		payload, err := decodeClientMessage(messageType, message)
+		if err != nil {
+			c.sendError("invalid_payload")
+			continue
+		}
+		if payload.Type != "replay_speed" {
+			c.sendError(ErrReplayOnly)
+			continue
+		}
+		if !validReplaySpeed(payload.Speed) {
+			c.sendError("invalid_speed")
+			continue
+		}
+		select {
+		case speeds <- payload.Speed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// queue hands a message to the write pump, waiting for room in the buffer
+// rather than dropping it: a replay is only useful complete
+func (c *Client) queue(ctx context.Context, env Envelope) bool {
+	payload, err := encodeEnvelope(env, c.binary)
+	if err != nil {
+		log.Printf("send envelope: %v", err)
+		return false
+	}
+	select {
+	case c.send <- payload:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
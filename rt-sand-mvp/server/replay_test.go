@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// applyDeltas plays deltas onto a board the way clients do
+func applyDeltas(board map[coord]Color, deltas []DeltaUpdate) {
+	for _, d := range deltas {
+		for _, c := range d.Removed {
+			delete(board, coord{c.X, c.Y})
+		}
+		for _, c := range d.Added {
+			board[coord{c.X, c.Y}] = c.Color
+		}
+	}
+}
+
+func boardOf(state BoardState) map[coord]Color {
+	board := make(map[coord]Color)
+	for _, c := range state.Cells {
+		board[coord{c.X, c.Y}] = c.Color
+	}
+	return board
+}
+
+func assertBoard(t *testing.T, want *Room, got map[coord]Color) {
+	t.Helper()
+	cells := want.getAllCells()
+	if len(got) != len(cells) {
+		t.Fatalf("expected %d cells, got %d", len(cells), len(got))
+	}
+	for _, c := range cells {
+		if col, ok := got[coord{c.X, c.Y}]; !ok || col != c.Color {
+			t.Fatalf("cell (%d,%d): expected color %d, got %d (present=%v)", c.X, c.Y, c.Color, col, ok)
+		}
+	}
+}
+
+func TestReplayRebuildsPastBoards(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			room := NewRoom()
+			if err := room.AttachStore("history", store); err != nil {
+				t.Fatalf("attach store: %v", err)
+			}
+			playStream(t, room, 400, 200)
+			latest := room.Seq
+
+			// The whole history from the empty board ends on the live board
+			all, err := room.Replay(0, latest+100)
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if all.To != latest || len(all.Board.Cells) != 0 {
+				t.Fatalf("expected an empty board up to seq %d, got to=%d with %d cells", latest, all.To, len(all.Board.Cells))
+			}
+			board := boardOf(all.Board)
+			applyDeltas(board, all.Deltas)
+			assertBoard(t, room, board)
+
+			// Boards on either side of the snapshot agree with the full replay
+			for _, mid := range []uint64{latest / 4, latest / 2, latest * 3 / 4} {
+				head, err := room.Replay(0, mid)
+				if err != nil {
+					t.Fatalf("replay to %d: %v", mid, err)
+				}
+				at, err := room.Replay(mid, mid)
+				if err != nil {
+					t.Fatalf("board at %d: %v", mid, err)
+				}
+				if len(at.Deltas) != 0 {
+					t.Fatalf("expected no deltas at a single seq, got %d", len(at.Deltas))
+				}
+				want := boardOf(head.Board)
+				applyDeltas(want, head.Deltas)
+				got := boardOf(at.Board)
+				if len(got) != len(want) {
+					t.Fatalf("seq %d: expected %d cells, got %d", mid, len(want), len(got))
+				}
+				for pos, col := range want {
+					if got[pos] != col {
+						t.Fatalf("seq %d: cell %v differs", mid, pos)
+					}
+				}
+
+				tail, err := room.Replay(mid, latest)
+				if err != nil {
+					t.Fatalf("replay from %d: %v", mid, err)
+				}
+				board := boardOf(tail.Board)
+				applyDeltas(board, tail.Deltas)
+				assertBoard(t, room, board)
+			}
+
+			if _, err := room.Replay(latest+1, latest+2); err != ErrReplayRange {
+				t.Fatalf("expected ErrReplayRange past the latest seq, got %v", err)
+			}
+			if _, err := room.Replay(5, 4); err != ErrReplayRange {
+				t.Fatalf("expected ErrReplayRange for from > to, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewRoom().Replay(0, 1); err != ErrNoStore {
+		t.Fatalf("expected ErrNoStore, got %v", err)
+	}
+}
+
+func TestReplayStartsAtRecordedHistory(t *testing.T) {
+	// Played before the room had a journal, as rooms migrated from
+	// chunk-only storage were
+	room := NewRoom()
+	for i := int64(0); i < 5; i++ {
+		room.ProcessMove(MoveRequest{X: i * 2, Y: 0, Color: ColorRed})
+	}
+	if err := room.AttachStore("migrated", NewMemoryStore()); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	for i := int64(0); i < 3; i++ {
+		room.ProcessMove(MoveRequest{X: i * 2, Y: 5, Color: ColorBlue})
+	}
+
+	_, err := room.Replay(0, room.Seq)
+	var tooEarly *ReplayTooEarlyError
+	if !errors.As(err, &tooEarly) || tooEarly.Earliest != 5 || replayStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expected history to start at seq 5, got %v", err)
+	}
+	replay, err := room.replayQuery(url.Values{})
+	if err != nil || replay.From != 5 || len(replay.Board.Cells) != 5 || len(replay.Deltas) != 3 {
+		t.Fatalf("expected a replay from seq 5, got %+v, %v", replay, err)
+	}
+	board := boardOf(replay.Board)
+	applyDeltas(board, replay.Deltas)
+	assertBoard(t, room, board)
+}
+
+func TestReplayOverHTTPAndWebSocket(t *testing.T) {
+	rm, srv := adminServer(t, NewMemoryStore())
+	if _, err := rm.CreateRoom("replay", RoomSettings{}); err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	player := joinWithColor(t, srv, "replay", ColorBlack)
+	for i := 0; i < 5; i++ {
+		player.WriteJSON(map[string]interface{}{"type": "move", "x": i, "y": 0, "color": int(ColorBlack)})
+		for {
+			var env Envelope
+			if err := player.ReadJSON(&env); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if env.Type == "move_result" {
+				if !env.MoveResult.Accepted {
+					t.Fatalf("move rejected: %s", env.MoveResult.Reason)
+				}
+				break
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ServeRoomAPI(rm, rec, httptest.NewRequest(http.MethodGet, "/api/rooms/replay/replay?from=2", nil))
+	var replay Replay
+	if err := json.Unmarshal(rec.Body.Bytes(), &replay); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("get replay: %d %v", rec.Code, err)
+	}
+	if replay.From != 2 || replay.To != 5 || len(replay.Board.Cells) != 2 || len(replay.Deltas) != 3 {
+		t.Fatalf("unexpected replay %+v", replay)
+	}
+	rec = httptest.NewRecorder()
+	ServeRoomAPI(rm, rec, httptest.NewRequest(http.MethodGet, "/api/rooms/replay/replay?from=9", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 past the latest seq, got %d", rec.Code)
+	}
+
+	// A replay viewer starts paused and is not counted as in the room
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=replay&replay=1&from=1&speed=0"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	read := func() Envelope {
+		var env Envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return env
+	}
+	if env := read(); env.Type != "room_info" || env.RoomInfo.SpectatorCount != 0 {
+		t.Fatalf("expected room_info without the viewer, got %+v", env)
+	}
+	if env := read(); env.Type != "board_state" || env.BoardState.ServerSeq != 1 {
+		t.Fatalf("expected the board at seq 1, got %+v", env)
+	}
+	conn.WriteJSON(map[string]interface{}{"type": "move", "x": 9, "y": 9, "color": int(ColorBlack)})
+	if env := read(); env.Type != "move_result" || env.MoveResult.Reason != ErrReplayOnly {
+		t.Fatalf("expected a replay error, got %+v", env)
+	}
+	conn.WriteJSON(map[string]interface{}{"type": "replay_speed", "speed": MaxReplaySpeed})
+	for seq := uint64(2); seq <= 5; seq++ {
+		if env := read(); env.Type != "delta_update" || env.DeltaUpdate.ServerSeq != seq {
+			t.Fatalf("expected the delta of seq %d, got %+v", seq, env)
+		}
+	}
+	if env := read(); env.Type != "replay_done" {
+		t.Fatalf("expected replay_done, got %+v", env)
+	}
+}
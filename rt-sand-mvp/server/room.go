@@ -2,9 +2,9 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"sync"
+	"time"
 )
 
 type MoveRequest struct {
@@ -29,6 +29,12 @@ type MoveResult struct {
 	Removed   []Cell `json:"removed,omitempty"`
 	Added     *Cell  `json:"added,omitempty"`
 	ServerSeq uint64 `json:"server_seq"`
+	// RetryAfter is set with reason "cooldown": milliseconds until the
+	// next move will be accepted
+	RetryAfter int64 `json:"retry_after_ms,omitempty"`
+	// FreeColors is set with reason "color_taken": palette colors nobody
+	// holds
+	FreeColors ColorList `json:"free_colors,omitempty"`
 }
 
 type DeltaUpdate struct {
@@ -37,9 +43,14 @@ type DeltaUpdate struct {
 	ServerSeq uint64 `json:"server_seq"`
 }
 
+// BoardState lists stones on the board. When Chunks is set the state only
+// covers those chunks and clients replace just that part of their board.
+// On the wire and in snapshots the stones are sent as Packed chunks.
 type BoardState struct {
-	Cells     []Cell `json:"cells"`
-	ServerSeq uint64 `json:"server_seq"`
+	Cells     []Cell        `json:"cells,omitempty"`
+	Chunks    []ChunkID     `json:"chunks,omitempty"`
+	Packed    []PackedChunk `json:"packed,omitempty"`
+	ServerSeq uint64        `json:"server_seq"`
 }
 
 type Envelope struct {
@@ -47,6 +58,11 @@ type Envelope struct {
 	MoveResult  *MoveResult  `json:"move_result,omitempty"`
 	DeltaUpdate *DeltaUpdate `json:"delta_update,omitempty"`
 	BoardState  *BoardState  `json:"board_state,omitempty"`
+	ChunkState  *ChunkState  `json:"chunk_state,omitempty"`
+	RoomInfo    *RoomInfo    `json:"room_info,omitempty"`
+	Score       *Score       `json:"score,omitempty"`
+	Leaderboard *Leaderboard `json:"leaderboard,omitempty"`
+	Session     *Session     `json:"session,omitempty"`
 }
 
 type coord struct {
@@ -54,56 +70,233 @@ type coord struct {
 }
 
 type Room struct {
-	Inbox        chan MoveRequest
-	StateInbox   chan GetStateRequest
-	ResetInbox   chan ResetRequest
-	Chunks       map[ChunkID]*Chunk
-	Seq          uint64
-	clients      map[*Client]struct{}
-	clMu         sync.RWMutex
+	ID             string
+	Rules          RuleSet    // fixed when the room is created
+	MaxPlayers     int        // player connections allowed at once, 0 is unlimited
+	Access         RoomAccess // fixed when the room is created, see private.go
+	DisplayName    string     // fixed when the room is created, may be empty
+	Owner          string
+	CreatedAt      time.Time
+	Inbox          chan MoveRequest
+	StateInbox     chan GetStateRequest
+	ResetInbox     chan ResetRequest
+	SubscribeInbox chan SubscribeRequest
+	Chunks         map[ChunkID]*Chunk
+	Seq            uint64
+	Config         RoomConfig
+	ChunksInbox    chan ChunksRequest
+	ScoreInbox     chan ScoreRequest
+	BoardInbox     chan LeaderboardRequest
+	SessionInbox   chan SessionRequest
+	AdminInbox     chan AdminRequest
+	clients        map[*Client]string // session of each player, "" until known
+	clMu           sync.RWMutex
+
+	// Persistence state, owned by the room goroutine
+	store                Store
+	dirty                map[ChunkID]struct{}
+	savedSeq             uint64
+	replaying            bool
+	changesSinceSnapshot int
+	journalGap           bool // an entry failed to append, see noteChange
+
+	// chunkClock orders chunk versions
+	chunkClock uint64
+
+	// Zobrist hash of the board and ko state, see ko.go
+	hash uint64
+	ko   koTracker
+
+	// Per-chunk scoring work, see score.go
+	scoreCache map[ChunkID]*scoreCacheEntry
+
+	// Per-color counters, see leaderboard.go. statsPending means clients
+	// have not seen the latest values, statsDirty that the store has not.
+	stats        map[Color]*ColorStats
+	statsPending bool
+	statsDirty   bool
+
+	// Move rate limits per color; per-client buckets live on the Client
+	colorBuckets map[Color]*tokenBucket
+
+	// Moves waiting for the end of the tick in tick mode, see tick.go
+	tickMoves []MoveRequest
+
+	// Colors held by player sessions, see session.go. seatsDirty means the
+	// store has not seen the latest seats.
+	seats      map[Color]*Seat
+	seatsDirty bool
+	taken      ColorList           // held colors for Info, guarded by clMu
+	seated     map[string]struct{} // sessions holding seats, guarded by clMu
+	conns      map[*Client]string  // session of each joined connection
+
+	// stop ends Run for rooms started by a RoomManager; done is closed when
+	// Run returns
+	stop context.CancelFunc
+	done chan struct{}
+
+	// emptySince is when the last client left; retired rooms are being
+	// stopped and take no new clients. Both are guarded by clMu.
+	emptySince time.Time
+	retired    bool
 }
 
 func NewRoom() *Room {
 	return &Room{
-		Inbox:      make(chan MoveRequest, 1024),
-		StateInbox: make(chan GetStateRequest, 64),
-		ResetInbox: make(chan ResetRequest, 16),
-		Chunks:     make(map[ChunkID]*Chunk),
-		clients:    make(map[*Client]struct{}),
+		Inbox:          make(chan MoveRequest, 1024),
+		StateInbox:     make(chan GetStateRequest, 64),
+		ResetInbox:     make(chan ResetRequest, 16),
+		SubscribeInbox: make(chan SubscribeRequest, 64),
+		ChunksInbox:    make(chan ChunksRequest, 64),
+		ScoreInbox:     make(chan ScoreRequest, 16),
+		BoardInbox:     make(chan LeaderboardRequest, 64),
+		SessionInbox:   make(chan SessionRequest, 64),
+		AdminInbox:     make(chan AdminRequest, 4),
+		Chunks:         make(map[ChunkID]*Chunk),
+		Config:         DefaultRoomConfig(),
+		clients:        make(map[*Client]string),
+		dirty:          make(map[ChunkID]struct{}),
+		done:           make(chan struct{}),
+		emptySince:     time.Now(),
 	}
 }
 
 func (r *Room) Run(ctx context.Context) {
+	defer close(r.done)
+	var flushC, snapC, boardC, tickC, seatC <-chan time.Time
+	if r.Rules.TickMS > 0 {
+		ticker := time.NewTicker(r.Rules.tick())
+		defer ticker.Stop()
+		tickC = ticker.C
+	}
+	if r.Config.SeatGrace > 0 {
+		ticker := time.NewTicker(seatSweepInterval(r.Config.SeatGrace))
+		defer ticker.Stop()
+		seatC = ticker.C
+	}
+	if r.Config.LeaderboardInterval > 0 {
+		ticker := time.NewTicker(r.Config.LeaderboardInterval)
+		defer ticker.Stop()
+		boardC = ticker.C
+	}
+	var flushQ chan flushBatch
+	var snapQ chan snapshotJob
+	var flushDone, snapDone chan struct{}
+	if r.store != nil {
+		// Without an interval dirty chunks are only written when the room stops
+		if r.Config.FlushInterval > 0 {
+			ticker := time.NewTicker(r.Config.FlushInterval)
+			defer ticker.Stop()
+			flushC = ticker.C
+		}
+		flushQ = make(chan flushBatch, 1)
+		flushDone = make(chan struct{})
+		go r.flushWorker(flushQ, flushDone)
+
+		if r.Config.SnapshotInterval > 0 {
+			snapTicker := time.NewTicker(r.Config.SnapshotInterval)
+			defer snapTicker.Stop()
+			snapC = snapTicker.C
+		}
+		snapQ = make(chan snapshotJob, 1)
+		snapDone = make(chan struct{})
+		go r.snapshotWorker(snapQ, snapDone)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
+			if flushQ != nil {
+				if batch, ok := r.takeDirty(); ok {
+					flushQ <- batch
+					r.commitDirty(batch)
+				}
+				close(flushQ)
+				<-flushDone
+			}
+			if snapQ != nil {
+				// A final snapshot keeps the next recovery short
+				if r.changesSinceSnapshot > 0 {
+					snapQ <- r.takeSnapshotJob()
+				}
+				close(snapQ)
+				<-snapDone
+			}
 			return
+		case <-flushC:
+			if batch, ok := r.takeDirty(); ok {
+				select {
+				case flushQ <- batch:
+					r.commitDirty(batch)
+				default:
+					// Writer still busy; keep the chunks dirty for the next tick
+				}
+			}
+		case <-tickC:
+			r.endTick(snapQ)
+		case <-boardC:
+			r.pushLeaderboard()
+		case now := <-seatC:
+			r.releaseSeats(now)
+		case <-snapC:
+			if r.changesSinceSnapshot > 0 {
+				r.requestSnapshot(snapQ)
+			}
 		case req := <-r.StateInbox:
-			state := r.GetBoardState()
 			if req.Player != nil {
+				state := r.boardStateFor(req.Player)
 				req.Player.sendEnvelope(Envelope{Type: "board_state", BoardState: &state})
 			}
+		case req := <-r.SubscribeInbox:
+			// Only ship the chunks the client has just scrolled into
+			added := r.subscribe(req.Player, req.Chunks)
+			state := r.chunksState(added)
+			req.Player.sendEnvelope(Envelope{Type: "board_state", BoardState: &state})
+		case req := <-r.ChunksInbox:
+			r.serveChunks(req)
+		case req := <-r.ScoreInbox:
+			r.serveScore(req)
+		case req := <-r.BoardInbox:
+			r.serveLeaderboard(req)
+		case req := <-r.SessionInbox:
+			r.serveSession(req)
+		case req := <-r.AdminInbox:
+			r.serveAdmin(req, snapQ)
 		case req := <-r.ResetInbox:
+			if !r.holdsSeat(req.Player, req.Color) {
+				req.Player.sendError(ErrColorNotHeld)
+				continue
+			}
 			// Clear only the requesting player's color
 			delta := r.ResetBoardColor(req.Color)
+			r.noteChange(snapQ)
 			r.broadcast(delta)
 			if req.Player != nil {
-				state := r.GetBoardState()
+				state := r.boardStateFor(req.Player)
 				req.Player.sendEnvelope(Envelope{Type: "board_state", BoardState: &state})
 			}
 		case req := <-r.Inbox:
+			if !r.holdsSeat(req.Player, req.Color) {
+				req.Player.sendError(ErrColorNotHeld)
+				continue
+			}
+			if result, ok := r.admitMove(req, time.Now()); !ok {
+				req.Player.sendEnvelope(Envelope{Type: "move_result", MoveResult: &result})
+				continue
+			}
+			if r.Rules.TickMS > 0 {
+				if result, ok := r.queueTickMove(req); !ok {
+					req.Player.sendEnvelope(Envelope{Type: "move_result", MoveResult: &result})
+				}
+				continue
+			}
 			result := r.ProcessMove(req)
 			if req.Player != nil {
 				req.Player.sendEnvelope(Envelope{Type: "move_result", MoveResult: &result})
 			}
 			if result.Accepted {
-				var delta DeltaUpdate
-				delta.ServerSeq = result.ServerSeq
-				if result.Added != nil {
-					delta.Added = append(delta.Added, *result.Added)
-				}
-				delta.Removed = append(delta.Removed, result.Removed...)
-				r.broadcast(delta)
+				r.noteChange(snapQ)
+				r.broadcast(result.delta())
 			}
 		}
 	}
@@ -111,28 +304,53 @@ func (r *Room) Run(ctx context.Context) {
 
 func (r *Room) broadcast(delta DeltaUpdate) {
 	env := Envelope{Type: "delta_update", DeltaUpdate: &delta}
-	payload, err := json.Marshal(env)
-	if err != nil {
-		log.Printf("broadcast marshal: %v", err)
-		return
-	}
+	// Encode once per wire format, only if some client needs it
+	var payloads [2][]byte
+	touched := deltaChunks(delta)
 	r.clMu.RLock()
 	defer r.clMu.RUnlock()
 	for c := range r.clients {
-		c.deliver(payload)
+		if !c.wants(touched) {
+			continue
+		}
+		i := 0
+		if c.binary {
+			i = 1
+		}
+		if payloads[i] == nil {
+			payload, err := encodeEnvelope(env, c.binary)
+			if err != nil {
+				log.Printf("broadcast marshal: %v", err)
+				return
+			}
+			payloads[i] = payload
+		}
+		c.deliver(payloads[i])
 	}
 }
 
-func (r *Room) addClient(c *Client) {
+// addClient adds a client unless the room is being stopped or, for
+// players, is full
+func (r *Room) addClient(c *Client) error {
 	r.clMu.Lock()
 	defer r.clMu.Unlock()
-	r.clients[c] = struct{}{}
+	if r.retired {
+		return errRoomRetired
+	}
+	if !c.spectator && !r.hasSlotFor(c.session) {
+		return ErrRoomFull
+	}
+	r.clients[c] = c.session
+	return nil
 }
 
 func (r *Room) removeClient(c *Client) {
 	r.clMu.Lock()
 	defer r.clMu.Unlock()
 	delete(r.clients, c)
+	if len(r.clients) == 0 {
+		r.emptySince = time.Now()
+	}
 }
 
 func (r *Room) GetBoardState() BoardState {
@@ -145,7 +363,16 @@ func (r *Room) GetBoardState() BoardState {
 func (r *Room) ResetBoard() DeltaUpdate {
 	// Capture current stones before clearing
 	removed := r.getAllCells()
+	for id := range r.Chunks {
+		r.markDirty(id)
+	}
 	r.Chunks = make(map[ChunkID]*Chunk)
+	r.hash = 0
+	r.resetKo()
+	// A cleared board starts a new game
+	r.stats = nil
+	r.statsChanged()
+	r.journal(MoveRecord{Kind: JournalReset, ServerSeq: r.Seq, Accepted: true})
 	return DeltaUpdate{
 		Removed:   removed,
 		Added:     nil,
@@ -164,7 +391,13 @@ func (r *Room) ResetBoardColor(color Color) DeltaUpdate {
 			removed = append(removed, c)
 		}
 	}
+	r.resetKo()
+	if len(removed) > 0 {
+		r.statsFor(color).Stones = 0
+		r.statsChanged()
+	}
 	// Do NOT increment sequence for personal reset per requirements
+	r.journal(MoveRecord{Kind: JournalResetColor, ServerSeq: r.Seq, Color: color, Accepted: true})
 	return DeltaUpdate{
 		Removed:   removed,
 		Added:     nil,
@@ -181,9 +414,11 @@ func (r *Room) neighbors4(x, y int64) [4]coord {
 	}
 }
 
-func (r *Room) bfsSameColor(seed coord, color Color, visited map[coord]struct{}) ([]coord, bool) {
+// bfsGroup collects the group at seed, joining stones of colors allied
+// with color, and reports whether it has a liberty
+func (r *Room) bfsGroup(seed coord, color Color, visited map[coord]struct{}) ([]Cell, bool) {
 	queue := []coord{seed}
-	component := make([]coord, 0, 16)
+	component := make([]Cell, 0, 16)
 	hasLiberty := false
 
 	for len(queue) > 0 {
@@ -194,7 +429,8 @@ func (r *Room) bfsSameColor(seed coord, color Color, visited map[coord]struct{})
 			continue
 		}
 		visited[cur] = struct{}{}
-		component = append(component, cur)
+		curColor, _ := r.getCell(cur.X, cur.Y)
+		component = append(component, Cell{X: cur.X, Y: cur.Y, Color: curColor})
 
 		for _, n := range r.neighbors4(cur.X, cur.Y) {
 			col, ok := r.getCell(n.X, n.Y)
@@ -202,7 +438,7 @@ func (r *Room) bfsSameColor(seed coord, color Color, visited map[coord]struct{})
 				hasLiberty = true
 				continue
 			}
-			if col == color {
+			if r.Rules.allied(col, color) {
 				if _, seen := visited[n]; !seen {
 					queue = append(queue, n)
 				}
@@ -212,46 +448,89 @@ func (r *Room) bfsSameColor(seed coord, color Color, visited map[coord]struct{})
 	return component, hasLiberty
 }
 
-func (r *Room) ProcessMove(req MoveRequest) MoveResult {
-	if _, err := chunkIDFor(req.X, req.Y); err != nil {
-		return MoveResult{Accepted: false, Reason: err.Error(), ServerSeq: r.Seq}
-	}
-	if _, occupied := r.getCell(req.X, req.Y); occupied {
-		return MoveResult{Accepted: false, Reason: "occupied", ServerSeq: r.Seq}
-	}
-
-	if err := r.setCell(req.X, req.Y, req.Color); err != nil {
-		return MoveResult{Accepted: false, Reason: err.Error(), ServerSeq: r.Seq}
-	}
-
+// captureOpponents removes enemy groups next to the new stone that have
+// no liberties left
+func (r *Room) captureOpponents(req MoveRequest) []Cell {
 	var removed []Cell
-	visitedOpp := make(map[coord]struct{})
-
+	visited := make(map[coord]struct{})
 	for _, nb := range r.neighbors4(req.X, req.Y) {
 		col, ok := r.getCell(nb.X, nb.Y)
-		if !ok || col == req.Color {
+		if !ok || r.Rules.allied(col, req.Color) {
 			continue
 		}
-		if _, seen := visitedOpp[nb]; seen {
+		if _, seen := visited[nb]; seen {
 			continue
 		}
-		comp, hasLiberty := r.bfsSameColor(nb, col, visitedOpp)
+		comp, hasLiberty := r.bfsGroup(nb, col, visited)
 		if !hasLiberty {
 			for _, c := range comp {
 				r.removeCell(c.X, c.Y)
-				removed = append(removed, Cell{X: c.X, Y: c.Y, Color: col})
 			}
+			removed = append(removed, comp...)
 		}
 	}
+	return removed
+}
 
-	visitedSelf := make(map[coord]struct{})
-	selfComp, selfLiberty := r.bfsSameColor(coord{X: req.X, Y: req.Y}, req.Color, visitedSelf)
-	if !selfLiberty {
-		for _, c := range selfComp {
-			r.removeCell(c.X, c.Y)
-			removed = append(removed, Cell{X: c.X, Y: c.Y, Color: req.Color})
+// captureSelf removes the mover's group if it has no liberties
+func (r *Room) captureSelf(req MoveRequest) []Cell {
+	comp, hasLiberty := r.bfsGroup(coord{X: req.X, Y: req.Y}, req.Color, make(map[coord]struct{}))
+	if hasLiberty {
+		return nil
+	}
+	for _, c := range comp {
+		r.removeCell(c.X, c.Y)
+	}
+	return comp
+}
+
+// delta is the board change of an accepted move
+func (res MoveResult) delta() DeltaUpdate {
+	delta := DeltaUpdate{ServerSeq: res.ServerSeq}
+	if res.Added != nil {
+		delta.Added = append(delta.Added, *res.Added)
+	}
+	delta.Removed = append(delta.Removed, res.Removed...)
+	return delta
+}
+
+func (r *Room) ProcessMove(req MoveRequest) MoveResult {
+	if _, err := chunkIDFor(req.X, req.Y); err != nil {
+		return MoveResult{Accepted: false, Reason: err.Error(), ServerSeq: r.Seq}
+	}
+	if _, occupied := r.getCell(req.X, req.Y); occupied {
+		return MoveResult{Accepted: false, Reason: "occupied", ServerSeq: r.Seq}
+	}
+	if r.Rules.Ko == KoSuperko && r.ko.history == nil {
+		r.resetKo()
+	}
+
+	if err := r.setCell(req.X, req.Y, req.Color); err != nil {
+		return MoveResult{Accepted: false, Reason: err.Error(), ServerSeq: r.Seq}
+	}
+
+	var captured, lost []Cell
+	if r.Rules.SelfCaptureFirst {
+		// A group without liberties dies before it can capture
+		if lost = r.captureSelf(req); lost == nil {
+			captured = r.captureOpponents(req)
 		}
+	} else {
+		captured = r.captureOpponents(req)
+		lost = r.captureSelf(req)
+	}
+	removed := append(captured[:len(captured):len(captured)], lost...)
+	if lost != nil && r.Rules.ForbidSuicide {
+		r.undoMove(req, removed)
+		return MoveResult{Accepted: false, Reason: "suicide", ServerSeq: r.Seq}
+	}
+
+	if r.violatesKo(req, removed) {
+		r.undoMove(req, removed)
+		return MoveResult{Accepted: false, Reason: "ko", ServerSeq: r.Seq}
 	}
+	r.recordKo(req, removed)
+	r.countMove(req, captured, removed)
 
 	r.Seq++
 	result := MoveResult{
@@ -262,5 +541,6 @@ func (r *Room) ProcessMove(req MoveRequest) MoveResult {
 	if r.hasStone(req.X, req.Y) {
 		result.Added = &Cell{X: req.X, Y: req.Y, Color: req.Color}
 	}
+	r.journal(MoveRecord{Kind: JournalMove, ServerSeq: r.Seq, X: req.X, Y: req.Y, Color: req.Color, Accepted: true})
 	return result
 }
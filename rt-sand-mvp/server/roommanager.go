@@ -2,72 +2,288 @@ package server
 
 import (
 	"context"
+	"errors"
+	"log"
 	"sync"
+	"time"
+)
+
+// DefaultRoomID is the room of connections that name none
+const DefaultRoomID = "default"
+
+var (
+	// ErrRoomExists is returned when creating a room whose name is taken
+	ErrRoomExists = errors.New("room already exists")
+	// ErrRoomNotLive is returned for rooms the manager is not running
+	ErrRoomNotLive = errors.New("room not live")
 )
 
 // RoomManager manages multiple game rooms
 type RoomManager struct {
-	rooms map[string]*Room
-	mu    sync.RWMutex
-	ctx   context.Context
+	rooms  map[string]*Room
+	mu     sync.RWMutex
+	ctx    context.Context
+	store  Store
+	config RoomConfig
+	wg     sync.WaitGroup
+
+	// hibernated rooms were stopped while idle and stay listed until woken
+	hibernated map[string]RoomInfo
+	// stopping rooms are unlinked but may still be flushing; each channel
+	// closes once the room's state is in the store
+	stopping map[string]chan struct{}
+}
+
+// NewRoomManager creates a new room manager. Rooms are persisted to store;
+// a nil store keeps them in memory only, and they are never hibernated.
+// Rooms saved by an earlier run start out listed as hibernated.
+func NewRoomManager(ctx context.Context, store Store, config RoomConfig) *RoomManager {
+	rm := &RoomManager{
+		rooms:      make(map[string]*Room),
+		hibernated: make(map[string]RoomInfo),
+		stopping:   make(map[string]chan struct{}),
+		ctx:        ctx,
+		store:      store,
+		config:     config,
+	}
+	if store != nil {
+		rm.loadHibernated()
+	}
+	if store != nil && config.IdleTimeout > 0 {
+		rm.wg.Add(1)
+		go rm.evictIdle()
+	}
+	return rm
 }
 
-// NewRoomManager creates a new room manager
-func NewRoomManager(ctx context.Context) *RoomManager {
-	return &RoomManager{
-		rooms: make(map[string]*Room),
-		ctx:   ctx,
+// RoomSettings are chosen when a room is created and never change
+type RoomSettings struct {
+	DisplayName string   // shown in the lobby, the room ID when empty
+	Owner       string   // creator's name, informational only
+	Rules       *RuleSet // nil takes the configured default
+	MaxPlayers  int      // 0 takes the configured default
+	Access      RoomAccess
+}
+
+// defaultSettings are the configured rules and capacity, used for what
+// CreateRoom callers leave out and what old saved rooms lack
+func (rm *RoomManager) defaultSettings() RoomSettings {
+	rules := rm.config.Rules
+	return RoomSettings{Rules: &rules, MaxPlayers: rm.config.MaxPlayers}
+}
+
+// CreateRoom creates a room with the given settings. It fails with
+// ErrRoomExists if the room is live or was saved by an earlier run.
+func (rm *RoomManager) CreateRoom(roomID string, settings RoomSettings) (*Room, error) {
+	defaults := rm.defaultSettings()
+	if settings.Rules == nil {
+		settings.Rules = defaults.Rules
+	}
+	if settings.MaxPlayers == 0 {
+		settings.MaxPlayers = defaults.MaxPlayers
+	}
+	if err := settings.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validMaxPlayers(settings.MaxPlayers); err != nil {
+		return nil, err
+	}
+	rm.lockSettled(roomID)
+	defer rm.mu.Unlock()
+	if _, exists := rm.rooms[roomID]; exists {
+		return nil, ErrRoomExists
+	}
+	if _, exists := rm.hibernated[roomID]; exists {
+		return nil, ErrRoomExists
+	}
+	if rm.store != nil {
+		_, err := rm.store.LoadRoom(roomID)
+		if err == nil {
+			return nil, ErrRoomExists
+		}
+		if !errors.Is(err, ErrRoomNotFound) {
+			return nil, err
+		}
 	}
+	if err := rm.makeSpace(); err != nil {
+		return nil, err
+	}
+	return rm.startRoom(roomID, settings), nil
 }
 
-// GetOrCreateRoom gets an existing room or creates a new one
-func (rm *RoomManager) GetOrCreateRoom(roomID string) *Room {
-	// Use default room if no ID provided
-	if roomID == "" {
-		roomID = "default"
+// startRoom creates a room and starts its goroutine. Rooms restored from the
+// store keep the settings they were saved with. The caller holds rm.mu.
+func (rm *RoomManager) startRoom(roomID string, settings RoomSettings) *Room {
+	room := NewRoom()
+	room.ID = roomID
+	room.DisplayName = settings.DisplayName
+	room.Owner = settings.Owner
+	room.CreatedAt = time.Now()
+	room.Config = rm.config
+	room.Rules = *settings.Rules
+	room.MaxPlayers = settings.MaxPlayers
+	room.Access = settings.Access
+	if rm.store != nil {
+		// Rehydrate chunks and sequence from a previous run
+		if err := room.AttachStore(roomID, rm.store); err != nil {
+			log.Printf("room %s: load from store: %v", roomID, err)
+		}
 	}
+	rm.rooms[roomID] = room
+	delete(rm.hibernated, roomID)
+
+	// Start room in background; RemoveRoom stops it early
+	ctx, stop := context.WithCancel(rm.ctx)
+	room.stop = stop
+	rm.wg.Add(1)
+	go func() {
+		defer rm.wg.Done()
+		room.Run(ctx)
+	}()
+
+	return room
+}
 
-	// Try read lock first to check if room exists
+// Wait blocks until every room goroutine has stopped and flushed its state
+func (rm *RoomManager) Wait() {
+	rm.wg.Wait()
+}
+
+// GetRoom is OpenRoom for callers that only care whether the room is there
+func (rm *RoomManager) GetRoom(roomID string) (*Room, bool) {
+	room, err := rm.OpenRoom(roomID)
+	return room, err == nil
+}
+
+// OpenRoom gets a live room, waking it if it is hibernated or was saved by
+// an earlier run, without creating one. It fails with ErrRoomNotFound for
+// rooms that were never created and with ErrTooManyRooms when the live room
+// cap is hit.
+func (rm *RoomManager) OpenRoom(roomID string) (*Room, error) {
 	rm.mu.RLock()
 	room, exists := rm.rooms[roomID]
 	rm.mu.RUnlock()
-
 	if exists {
-		return room
+		return room, nil
 	}
 
-	// Room doesn't exist, acquire write lock to create it
-	rm.mu.Lock()
+	rm.lockSettled(roomID)
 	defer rm.mu.Unlock()
-
-	// Double-check in case another goroutine created it
 	if room, exists := rm.rooms[roomID]; exists {
-		return room
+		return room, nil
 	}
+	if _, ok := rm.hibernated[roomID]; !ok {
+		if rm.store == nil {
+			return nil, ErrRoomNotFound
+		}
+		if _, err := rm.store.LoadRoom(roomID); err != nil {
+			return nil, err
+		}
+	}
+	if err := rm.makeSpace(); err != nil {
+		return nil, err
+	}
+	// The saved record replaces the default settings
+	return rm.startRoom(roomID, rm.defaultSettings()), nil
+}
 
-	// Create new room
-	room = NewRoom()
-	rm.rooms[roomID] = room
+// RemoveRoom stops a room's goroutine, waits for it to write its final
+// state and forgets the room. Saved state stays in the store, so the room
+// comes back on its next use.
+func (rm *RoomManager) RemoveRoom(roomID string) error {
+	rm.mu.Lock()
+	room, err := rm.removeRoom(roomID)
+	rm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	rm.awaitStop(room)
+	return nil
+}
 
-	// Start room in background
-	go room.Run(rm.ctx)
+// CloseRoom disconnects everyone with reason, stops the room and deletes
+// its saved state. Hibernated rooms and rooms only in the store are deleted
+// too; ErrRoomNotFound means there was no such room anywhere.
+func (rm *RoomManager) CloseRoom(roomID, reason string) error {
+	rm.lockSettled(roomID)
+	if _, live := rm.rooms[roomID]; !live {
+		defer rm.mu.Unlock()
+		delete(rm.hibernated, roomID)
+		if rm.store == nil {
+			return ErrRoomNotFound
+		}
+		if _, err := rm.store.LoadRoom(roomID); err != nil {
+			return err
+		}
+		return rm.store.DeleteRoom(roomID)
+	}
+	room, err := rm.removeRoom(roomID)
+	rm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	room.closeClients(CloseRoomClosed, reason)
+	// The room stays marked as stopping until its state is deleted, so its
+	// final flush cannot bring it back
+	<-room.done
+	defer rm.settle(roomID)
+	if rm.store != nil {
+		return rm.store.DeleteRoom(roomID)
+	}
+	return nil
+}
 
-	return room
+// removeRoom stops and forgets a room. The caller holds rm.mu; the room
+// stays marked as stopping, so nobody can revive it from the store before
+// it has been flushed.
+func (rm *RoomManager) removeRoom(roomID string) (*Room, error) {
+	room, ok := rm.rooms[roomID]
+	if !ok {
+		return nil, ErrRoomNotLive
+	}
+	room.retire(time.Time{})
+	rm.stopRoom(roomID, room)
+	return room, nil
 }
 
-// GetRoom gets an existing room without creating one
-func (rm *RoomManager) GetRoom(roomID string) (*Room, bool) {
-	rm.mu.RLock()
-	defer rm.mu.RUnlock()
-	room, exists := rm.rooms[roomID]
-	return room, exists
+// stopRoom forgets a retired room, stops its goroutine and marks it as
+// stopping. The caller holds rm.mu and must call awaitStop or settle once
+// it has released it.
+func (rm *RoomManager) stopRoom(roomID string, room *Room) {
+	delete(rm.rooms, roomID)
+	rm.stopping[roomID] = make(chan struct{})
+	room.stop()
+}
+
+// awaitStop waits for a stopped room to flush and then lets it be reopened
+func (rm *RoomManager) awaitStop(room *Room) {
+	<-room.done
+	rm.settle(room.ID)
 }
 
-// RemoveRoom removes a room (for cleanup)
-func (rm *RoomManager) RemoveRoom(roomID string) {
+// settle clears a room's stopping mark and wakes anyone waiting on it
+func (rm *RoomManager) settle(roomID string) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
-	delete(rm.rooms, roomID)
+	if settled, ok := rm.stopping[roomID]; ok {
+		delete(rm.stopping, roomID)
+		close(settled)
+	}
+}
+
+// lockSettled takes rm.mu once roomID is no longer stopping, so its final
+// state is in the store before anyone loads it
+func (rm *RoomManager) lockSettled(roomID string) {
+	rm.mu.Lock()
+	for {
+		settled, ok := rm.stopping[roomID]
+		if !ok {
+			return
+		}
+		rm.mu.Unlock()
+		<-settled
+		rm.mu.Lock()
+	}
 }
 
 // ListRooms returns a list of all active room IDs
@@ -84,24 +300,66 @@ func (rm *RoomManager) ListRooms() []string {
 
 // GetRoomInfo returns room information for the lobby
 type RoomInfo struct {
-	ID          string `json:"id"`
-	PlayerCount int    `json:"player_count"`
+	ID          string  `json:"id"`
+	PlayerCount int     `json:"player_count"`
+	Rules       RuleSet `json:"rules"`
+	// Spectators watch without playing and are not counted as players
+	SpectatorCount int `json:"spectator_count"`
+	MaxPlayers     int `json:"max_players"`
+	// Private rooms are left out of the room list, see private.go
+	Private     bool      `json:"private,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Owner       string    `json:"owner,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// TakenColors are held by player sessions, see session.go
+	TakenColors ColorList `json:"taken_colors"`
+	// Hibernating rooms are stopped until someone joins, see hibernate.go
+	Hibernating bool `json:"hibernating,omitempty"`
 }
 
 func (rm *RoomManager) GetRoomInfoList() []RoomInfo {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 
-	infos := make([]RoomInfo, 0, len(rm.rooms))
-	for id, room := range rm.rooms {
-		room.clMu.RLock()
-		count := len(room.clients)
-		room.clMu.RUnlock()
-
-		infos = append(infos, RoomInfo{
-			ID:          id,
-			PlayerCount: count,
-		})
+	infos := make([]RoomInfo, 0, len(rm.rooms)+len(rm.hibernated))
+	for _, room := range rm.rooms {
+		if !room.Access.Private {
+			infos = append(infos, room.Info())
+		}
+	}
+	for _, info := range rm.hibernated {
+		if !info.Private {
+			infos = append(infos, info)
+		}
 	}
 	return infos
 }
+
+// displayName falls back to the ID for rooms created without a name
+func (r *Room) displayName() string {
+	if r.DisplayName == "" {
+		return r.ID
+	}
+	return r.DisplayName
+}
+
+// Info describes the room for the lobby and for clients joining it
+func (r *Room) Info() RoomInfo {
+	r.clMu.RLock()
+	players := len(r.playerSlots())
+	spectators := r.spectatorCount()
+	taken := append(ColorList{}, r.taken...)
+	r.clMu.RUnlock()
+	return RoomInfo{
+		ID:             r.ID,
+		PlayerCount:    players,
+		SpectatorCount: spectators,
+		MaxPlayers:     r.MaxPlayers,
+		Private:        r.Access.Private,
+		DisplayName:    r.displayName(),
+		Owner:          r.Owner,
+		CreatedAt:      r.CreatedAt,
+		Rules:          r.Rules,
+		TakenColors:    taken,
+	}
+}
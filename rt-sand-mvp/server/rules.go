@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RuleSet is the set of play rules a room is created with. The zero value
+// is the original free-for-all: suicide allowed, opponents captured before
+// the mover's own group, every other color an enemy and no ko rule.
+type RuleSet struct {
+	Ko KoRule `json:"ko"`
+	// ForbidSuicide rejects moves that would remove the mover's own group
+	ForbidSuicide bool `json:"forbid_suicide"`
+	// SelfCaptureFirst checks the mover's group for liberties before
+	// capturing opponents, so a stone with no liberties dies even if it
+	// would have captured.
+	SelfCaptureFirst bool `json:"self_capture_first"`
+	// Alliances lists groups of colors that share liberties and never
+	// capture each other
+	Alliances []Alliance `json:"alliances,omitempty"`
+	// Teams turns on team mode: color c plays for team c % Teams, so six
+	// players on colors 0-5 with Teams 3 play 2v2v2. Teammates connect like
+	// alliances. It cannot be combined with explicit Alliances.
+	Teams int `json:"teams,omitempty"`
+	// TickMS turns on tick mode: moves are collected for this many
+	// milliseconds and resolved together, see ResolveTick. Zero plays moves
+	// in arrival order.
+	TickMS int `json:"tick_ms,omitempty"`
+}
+
+// MaxTeams bounds RuleSet.Teams
+const MaxTeams = 64
+
+// Alliance is a set of colors playing on one side
+type Alliance []Color
+
+// MarshalJSON writes the colors as numbers; a plain []uint8 would be base64
+func (a Alliance) MarshalJSON() ([]byte, error) {
+	colors := make([]int, len(a))
+	for i, c := range a {
+		colors[i] = int(c)
+	}
+	return json.Marshal(colors)
+}
+
+// Validate checks that every allied color is valid and in one alliance
+// only, and that team mode is configured sensibly
+func (rs RuleSet) Validate() error {
+	if rs.Teams < 0 || rs.Teams > MaxTeams {
+		return fmt.Errorf("teams must be between 0 and %d", MaxTeams)
+	}
+	if rs.Teams > 0 && len(rs.Alliances) > 0 {
+		return fmt.Errorf("teams and alliances cannot be combined")
+	}
+	if rs.TickMS != 0 && (rs.TickMS < MinTickMS || rs.TickMS > MaxTickMS) {
+		return fmt.Errorf("tick_ms must be 0 or between %d and %d", MinTickMS, MaxTickMS)
+	}
+	if rs.TickMS != 0 && rs.Ko != KoNone {
+		return fmt.Errorf("ko rules cannot be combined with tick mode")
+	}
+	seen := make(map[Color]bool)
+	for _, team := range rs.Alliances {
+		for _, c := range team {
+			if c == emptyCell {
+				return fmt.Errorf("invalid color %d in alliance", c)
+			}
+			if seen[c] {
+				return fmt.Errorf("color %d is in more than one alliance", c)
+			}
+			seen[c] = true
+		}
+	}
+	return nil
+}
+
+// TeamOf returns the team or alliance index of color, or -1 if it plays alone
+func (rs RuleSet) TeamOf(color Color) int {
+	if rs.Teams > 0 {
+		return int(color) % rs.Teams
+	}
+	for i, team := range rs.Alliances {
+		for _, c := range team {
+			if c == color {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// allied reports whether stones of colors a and b belong to the same side
+func (rs RuleSet) allied(a, b Color) bool {
+	if a == b {
+		return true
+	}
+	if rs.Teams > 0 {
+		return int(a)%rs.Teams == int(b)%rs.Teams
+	}
+	if len(rs.Alliances) == 0 {
+		return false
+	}
+	team := rs.TeamOf(a)
+	return team >= 0 && team == rs.TeamOf(b)
+}
+
+// MarshalText encodes the rule by name, e.g. "superko"
+func (k KoRule) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *KoRule) UnmarshalText(text []byte) error {
+	rule, err := ParseKoRule(string(text))
+	if err != nil {
+		return err
+	}
+	*k = rule
+	return nil
+}
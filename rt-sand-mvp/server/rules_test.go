@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// surround places black stones on the four neighbors of (0,0)
+func surround(t *testing.T, room *Room) {
+	t.Helper()
+	for _, m := range []MoveRequest{
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+		{X: 0, Y: -1, Color: ColorBlack},
+	} {
+		if res := room.ProcessMove(m); !res.Accepted {
+			t.Fatalf("setup move rejected: %s", res.Reason)
+		}
+	}
+}
+
+func TestForbidSuicide(t *testing.T) {
+	room := NewRoom()
+	room.Rules.ForbidSuicide = true
+	surround(t, room)
+	seq := room.Seq
+	res := room.ProcessMove(MoveRequest{X: 0, Y: 0, Color: ColorWhite})
+	if res.Accepted || res.Reason != "suicide" {
+		t.Fatalf("expected suicide rejection, got %+v", res)
+	}
+	if room.hasStone(0, 0) || room.Seq != seq {
+		t.Fatalf("rejected suicide changed the board")
+	}
+}
+
+func TestSelfCaptureFirst(t *testing.T) {
+	// White at (1,0) has one liberty at (0,0); black playing there captures
+	// it under the default rules but dies first when self capture comes first
+	setup := []MoveRequest{
+		{X: 2, Y: 0, Color: ColorBlack},
+		{X: 1, Y: 1, Color: ColorBlack},
+		{X: 1, Y: -1, Color: ColorBlack},
+		{X: 1, Y: 0, Color: ColorWhite},
+		{X: -1, Y: 0, Color: ColorWhite},
+		{X: 0, Y: 1, Color: ColorWhite},
+		{X: 0, Y: -1, Color: ColorWhite},
+	}
+	for _, selfFirst := range []bool{false, true} {
+		room := NewRoom()
+		room.Rules.SelfCaptureFirst = selfFirst
+		for _, m := range setup {
+			if res := room.ProcessMove(m); !res.Accepted {
+				t.Fatalf("setup move rejected: %s", res.Reason)
+			}
+		}
+		res := room.ProcessMove(MoveRequest{X: 0, Y: 0, Color: ColorBlack})
+		if !res.Accepted || len(res.Removed) != 1 {
+			t.Fatalf("selfFirst=%v: unexpected result %+v", selfFirst, res)
+		}
+		if got := res.Removed[0].Color; selfFirst != (got == ColorBlack) {
+			t.Fatalf("selfFirst=%v: removed %d stone", selfFirst, got)
+		}
+	}
+}
+
+func TestAlliancesShareLiberties(t *testing.T) {
+	room := NewRoom()
+	room.Rules.Alliances = []Alliance{{ColorWhite, ColorRed}}
+
+	// A red stone next to the white one keeps it alive
+	for _, m := range []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: 1, Y: 0, Color: ColorRed},
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+	} {
+		if res := room.ProcessMove(m); !res.Accepted {
+			t.Fatalf("setup move rejected: %s", res.Reason)
+		}
+	}
+	if res := room.ProcessMove(MoveRequest{X: 0, Y: -1, Color: ColorBlack}); len(res.Removed) != 0 {
+		t.Fatalf("allied group still has liberties, got %+v", res.Removed)
+	}
+
+	// Allies never capture each other
+	room.ProcessMove(MoveRequest{X: 5, Y: 6, Color: ColorWhite})
+	room.ProcessMove(MoveRequest{X: 5, Y: 4, Color: ColorWhite})
+	room.ProcessMove(MoveRequest{X: 4, Y: 5, Color: ColorWhite})
+	room.ProcessMove(MoveRequest{X: 5, Y: 5, Color: ColorRed})
+	if res := room.ProcessMove(MoveRequest{X: 6, Y: 5, Color: ColorWhite}); len(res.Removed) != 0 {
+		t.Fatalf("white captured its red ally: %+v", res.Removed)
+	}
+
+	// Filling the last liberty captures both colors together
+	res := room.ProcessMove(MoveRequest{X: 1, Y: 1, Color: ColorBlack})
+	res = room.ProcessMove(MoveRequest{X: 1, Y: -1, Color: ColorBlack})
+	res = room.ProcessMove(MoveRequest{X: 2, Y: 0, Color: ColorBlack})
+	if len(res.Removed) != 2 || res.Removed[0].Color == res.Removed[1].Color {
+		t.Fatalf("expected the white and red stones captured, got %+v", res.Removed)
+	}
+
+	if err := (RuleSet{Alliances: []Alliance{{ColorRed}, {ColorRed, ColorBlue}}}).Validate(); err == nil {
+		t.Fatalf("a color in two alliances should be invalid")
+	}
+}
+
+func TestTeamMode(t *testing.T) {
+	// Three teams: colors 0 and 3 play together against 1 and 4
+	room := NewRoom()
+	room.Rules.Teams = 3
+	if room.Rules.TeamOf(3) != 0 || room.Rules.TeamOf(4) != 1 {
+		t.Fatalf("unexpected team assignment")
+	}
+	for _, m := range []MoveRequest{
+		{X: 0, Y: 0, Color: 0},
+		{X: 1, Y: 0, Color: 3},
+		{X: -1, Y: 0, Color: 1},
+		{X: 0, Y: 1, Color: 4},
+		{X: 0, Y: -1, Color: 1},
+		{X: 1, Y: 1, Color: 4},
+		{X: 1, Y: -1, Color: 1},
+	} {
+		if res := room.ProcessMove(m); !res.Accepted || len(res.Removed) != 0 {
+			t.Fatalf("setup move %+v: %+v", m, res)
+		}
+	}
+	res := room.ProcessMove(MoveRequest{X: 2, Y: 0, Color: 4})
+	if len(res.Removed) != 2 {
+		t.Fatalf("expected the 0/3 group captured by its opponents, got %+v", res.Removed)
+	}
+
+	for _, rs := range []RuleSet{
+		{Teams: 2, Alliances: []Alliance{{ColorRed, ColorBlue}}},
+		{Teams: MaxTeams + 1},
+	} {
+		if rs.Validate() == nil {
+			t.Fatalf("%+v should be invalid", rs)
+		}
+	}
+}
+
+func TestCreateRoomWithRules(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemoryStore()
+	rm := NewRoomManager(ctx, store, DefaultRoomConfig())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeRooms(rm, w, r)
+	})
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	body := `{"id": "teams", "rules": {"ko": "superko", "forbid_suicide": true, "alliances": [[0, 1]]}}`
+	if code := post(body); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if code := post(body); code != http.StatusConflict {
+		t.Fatalf("expected 409 for a taken name, got %d", code)
+	}
+	if code := post(`{"id": "bad name!"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid name, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rooms", nil))
+	if !strings.Contains(rec.Body.String(), `"alliances":[[0,1]]`) || !strings.Contains(rec.Body.String(), `"ko":"superko"`) {
+		t.Fatalf("room list should report the rules: %s", rec.Body.String())
+	}
+	var infos []RoomInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil || len(infos) != 1 || !infos[0].Rules.ForbidSuicide {
+		t.Fatalf("rules should decode back: %+v, %v", infos, err)
+	}
+
+	// The rules come back with the room after a restart
+	cancel()
+	rm.Wait()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	rm = NewRoomManager(ctx, store, DefaultRoomConfig())
+	room, _ := rm.OpenRoom("teams")
+	if room.Rules.Ko != KoSuperko || !room.Rules.allied(ColorBlack, ColorWhite) {
+		t.Fatalf("rules lost on restart: %+v", room.Rules)
+	}
+}
@@ -0,0 +1,445 @@
+package server
+
+import (
+	"sort"
+)
+
+// MaxScoreChunks caps how many chunks one score request may cover
+const MaxScoreChunks = 4096
+
+// Rect is an inclusive rectangle of cells
+type Rect struct {
+	MinX int64 `json:"min_x"`
+	MinY int64 `json:"min_y"`
+	MaxX int64 `json:"max_x"`
+	MaxY int64 `json:"max_y"`
+}
+
+// normalized swaps the bounds so that Min <= Max
+func (rc Rect) normalized() Rect {
+	if rc.MinX > rc.MaxX {
+		rc.MinX, rc.MaxX = rc.MaxX, rc.MinX
+	}
+	if rc.MinY > rc.MaxY {
+		rc.MinY, rc.MaxY = rc.MaxY, rc.MinY
+	}
+	return rc
+}
+
+// ColorScore is the area score of one color: its stones plus the empty
+// points it alone surrounds
+type ColorScore struct {
+	Color     Color `json:"color"`
+	Stones    int   `json:"stones"`
+	Territory int   `json:"territory"`
+	Score     int   `json:"score"`
+}
+
+// Score is the result of scoring a region, best color first. Empty regions
+// bordered by several colors count as Neutral; regions reaching the edge of
+// the scored area are open to the infinite board and count for nobody.
+type Score struct {
+	Region    Rect         `json:"region"`
+	Colors    []ColorScore `json:"colors"`
+	Neutral   int          `json:"neutral"`
+	ServerSeq uint64       `json:"server_seq"`
+}
+
+// ScoreRequest asks the room goroutine to score Region, or the bounding box
+// of all stones when Region is nil. WebSocket clients get a score envelope;
+// HTTP handlers set Reply instead.
+type ScoreRequest struct {
+	Player *Client
+	Region *Rect
+	Reply  chan ScoreReply
+}
+
+type ScoreReply struct {
+	Score Score
+	Err   error
+}
+
+// Owners of an empty region; values >= 0 are colors
+const (
+	ownerNone  int16 = -1
+	ownerMixed int16 = -2
+)
+
+func mergeOwner(a, b int16) int16 {
+	switch {
+	case a == ownerNone || a == b:
+		return b
+	case b == ownerNone:
+		return a
+	}
+	return ownerMixed
+}
+
+// emptyRegion is a connected set of empty points within one chunk
+type emptyRegion struct {
+	size  int
+	owner int16
+	open  bool // touches the edge of the scored area
+}
+
+// Sides of a chunk clip in chunkScore.edges
+const (
+	edgeLeft = iota // x == clip.MinX
+	edgeRight
+	edgeTop // y == clip.MinY
+	edgeBottom
+)
+
+// chunkScore summarizes the part of a chunk inside a scored area. Regions
+// that cross into neighboring chunks are joined using the edges, which hold
+// a region index for empty points and stoneMark(color) for stones.
+type chunkScore struct {
+	clip    Rect
+	stones  map[Color]int
+	regions []emptyRegion
+	edges   [4][]int32
+}
+
+func stoneMark(color Color) int32 {
+	return -int32(color) - 1
+}
+
+// scoreCacheEntry keeps per-chunk work between score requests; it is valid
+// while the chunk is unchanged
+type scoreCacheEntry struct {
+	chunk   *Chunk
+	version uint64
+	bounds  Rect
+	summary *chunkScore
+}
+
+// chunkRect is the rectangle of cells covered by a chunk
+func chunkRect(id ChunkID) Rect {
+	x, y := int64(id.X)<<chunkBits, int64(id.Y)<<chunkBits
+	return Rect{MinX: x, MinY: y, MaxX: x + ChunkSize - 1, MaxY: y + ChunkSize - 1}
+}
+
+func intersect(a, b Rect) Rect {
+	return Rect{
+		MinX: max64(a.MinX, b.MinX),
+		MinY: max64(a.MinY, b.MinY),
+		MaxX: min64(a.MaxX, b.MaxX),
+		MaxY: min64(a.MaxY, b.MaxY),
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// scoreEntry returns the cache entry of a live chunk, resetting it if the
+// chunk changed since it was filled
+func (r *Room) scoreEntry(id ChunkID, ch *Chunk) *scoreCacheEntry {
+	if r.scoreCache == nil {
+		r.scoreCache = make(map[ChunkID]*scoreCacheEntry)
+	}
+	e, ok := r.scoreCache[id]
+	if ok && e.chunk == ch && e.version == ch.Version {
+		return e
+	}
+	e = &scoreCacheEntry{chunk: ch, version: ch.Version}
+	first := true
+	base := chunkRect(id)
+	ch.each(func(idx uint32, _ Color) {
+		x := base.MinX + int64(idx>>chunkBits)
+		y := base.MinY + int64(idx&chunkSizeMask)
+		if first {
+			e.bounds = Rect{MinX: x, MinY: y, MaxX: x, MaxY: y}
+			first = false
+			return
+		}
+		e.bounds.MinX, e.bounds.MaxX = min64(e.bounds.MinX, x), max64(e.bounds.MaxX, x)
+		e.bounds.MinY, e.bounds.MaxY = min64(e.bounds.MinY, y), max64(e.bounds.MaxY, y)
+	})
+	r.scoreCache[id] = e
+	return e
+}
+
+// occupiedBounds returns the bounding box of all stones
+func (r *Room) occupiedBounds() (Rect, bool) {
+	// Drop entries of chunks that have since been emptied
+	for id, e := range r.scoreCache {
+		if r.Chunks[id] != e.chunk {
+			delete(r.scoreCache, id)
+		}
+	}
+	var bounds Rect
+	found := false
+	for id, ch := range r.Chunks {
+		if ch.len() == 0 {
+			continue
+		}
+		b := r.scoreEntry(id, ch).bounds
+		if !found {
+			bounds, found = b, true
+			continue
+		}
+		bounds.MinX, bounds.MaxX = min64(bounds.MinX, b.MinX), max64(bounds.MaxX, b.MaxX)
+		bounds.MinY, bounds.MaxY = min64(bounds.MinY, b.MinY), max64(bounds.MaxY, b.MaxY)
+	}
+	return bounds, found
+}
+
+// summarize scores the part of a chunk inside area. ch may be nil for a
+// chunk without stones.
+func summarize(ch *Chunk, clip, area Rect) *chunkScore {
+	w, h := int(clip.MaxX-clip.MinX+1), int(clip.MaxY-clip.MinY+1)
+	s := &chunkScore{clip: clip, stones: make(map[Color]int)}
+	for side := range s.edges {
+		n := h
+		if side == edgeTop || side == edgeBottom {
+			n = w
+		}
+		s.edges[side] = make([]int32, n)
+	}
+	openEdge := openEdges(clip, area)
+
+	if ch == nil || ch.len() == 0 {
+		s.regions = []emptyRegion{{
+			size:  w * h,
+			owner: ownerNone,
+			open:  openEdge[0] || openEdge[1] || openEdge[2] || openEdge[3],
+		}}
+		return s
+	}
+
+	// labels holds stoneMark for stones, 0 for unvisited empty points and
+	// region index+1 once visited; cells are indexed x-major like localIndex
+	const unvisited = 0
+	labels := make([]int32, w*h)
+	base := chunkRect(ChunkID{X: ch.X, Y: ch.Y})
+	ch.each(func(idx uint32, color Color) {
+		x := base.MinX + int64(idx>>chunkBits)
+		y := base.MinY + int64(idx&chunkSizeMask)
+		if x < clip.MinX || x > clip.MaxX || y < clip.MinY || y > clip.MaxY {
+			return
+		}
+		labels[int(x-clip.MinX)*h+int(y-clip.MinY)] = stoneMark(color)
+		s.stones[color]++
+	})
+
+	var stack []int
+	for start := range labels {
+		if labels[start] != unvisited {
+			continue
+		}
+		region := emptyRegion{owner: ownerNone}
+		label := int32(len(s.regions)) + 1
+		labels[start] = label
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			region.size++
+			x, y := i/h, i%h
+			if (x == 0 && openEdge[edgeLeft]) || (x == w-1 && openEdge[edgeRight]) ||
+				(y == 0 && openEdge[edgeTop]) || (y == h-1 && openEdge[edgeBottom]) {
+				region.open = true
+			}
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= w || n[1] < 0 || n[1] >= h {
+					continue
+				}
+				j := n[0]*h + n[1]
+				switch l := labels[j]; {
+				case l == unvisited:
+					labels[j] = label
+					stack = append(stack, j)
+				case l < 0:
+					region.owner = mergeOwner(region.owner, int16(-l-1))
+				}
+			}
+		}
+		s.regions = append(s.regions, region)
+	}
+
+	edge := func(l int32) int32 {
+		if l > 0 {
+			return l - 1
+		}
+		return l
+	}
+	for y := 0; y < h; y++ {
+		s.edges[edgeLeft][y] = edge(labels[y])
+		s.edges[edgeRight][y] = edge(labels[(w-1)*h+y])
+	}
+	for x := 0; x < w; x++ {
+		s.edges[edgeTop][x] = edge(labels[x*h])
+		s.edges[edgeBottom][x] = edge(labels[x*h+h-1])
+	}
+	return s
+}
+
+// chunkSummary returns the summary of one chunk for area, reusing the
+// cached one when neither the chunk nor its clip changed
+func (r *Room) chunkSummary(id ChunkID, area Rect) *chunkScore {
+	clip := intersect(chunkRect(id), area)
+	ch, ok := r.Chunks[id]
+	if !ok {
+		return summarize(nil, clip, area)
+	}
+	e := r.scoreEntry(id, ch)
+	if e.summary == nil || e.summary.clip != clip || openEdges(e.summary.clip, area) != openEdges(clip, area) {
+		e.summary = summarize(ch, clip, area)
+	}
+	return e.summary
+}
+
+// openEdges reports which sides of clip lie on the edge of area
+func openEdges(clip, area Rect) [4]bool {
+	return [4]bool{clip.MinX == area.MinX, clip.MaxX == area.MaxX, clip.MinY == area.MinY, clip.MaxY == area.MaxY}
+}
+
+// ScoreRegion computes area scores for region, or for the bounding box of
+// all stones when region is nil. Work done for unchanged chunks is reused
+// from earlier calls. Must be called on the room goroutine.
+func (r *Room) ScoreRegion(region *Rect) (Score, error) {
+	var area Rect
+	if region != nil {
+		area = region.normalized()
+	} else {
+		bounds, ok := r.occupiedBounds()
+		if !ok {
+			return Score{ServerSeq: r.Seq}, nil
+		}
+		area = bounds
+	}
+	lo, errLo := chunkIDFor(area.MinX, area.MinY)
+	hi, errHi := chunkIDFor(area.MaxX, area.MaxY)
+	if errLo != nil || errHi != nil {
+		return Score{}, ErrOutOfBounds
+	}
+	cols, rows := int64(hi.X)-int64(lo.X)+1, int64(hi.Y)-int64(lo.Y)+1
+	if cols*rows > MaxScoreChunks {
+		return Score{}, ErrRegionTooLarge
+	}
+
+	// Summarize every chunk, numbering regions across the whole area
+	summaries := make([]*chunkScore, cols*rows)
+	offsets := make([]int32, cols*rows)
+	var regions []emptyRegion
+	stones := make(map[Color]int)
+	for cx := int64(0); cx < cols; cx++ {
+		for cy := int64(0); cy < rows; cy++ {
+			i := cx*rows + cy
+			s := r.chunkSummary(ChunkID{X: lo.X + int32(cx), Y: lo.Y + int32(cy)}, area)
+			summaries[i] = s
+			offsets[i] = int32(len(regions))
+			regions = append(regions, s.regions...)
+			for color, n := range s.stones {
+				stones[color] += n
+			}
+		}
+	}
+
+	// Join regions that continue across chunk borders
+	parent := make([]int32, len(regions))
+	for i := range parent {
+		parent[i] = int32(i)
+	}
+	var find func(int32) int32
+	find = func(i int32) int32 {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	join := func(a []int32, offA int32, b []int32, offB int32) {
+		for k := range a {
+			switch {
+			case a[k] >= 0 && b[k] >= 0:
+				ra, rb := find(a[k]+offA), find(b[k]+offB)
+				if ra != rb {
+					parent[ra] = rb
+				}
+			case a[k] >= 0:
+				regions[a[k]+offA].owner = mergeOwner(regions[a[k]+offA].owner, int16(-b[k]-1))
+			case b[k] >= 0:
+				regions[b[k]+offB].owner = mergeOwner(regions[b[k]+offB].owner, int16(-a[k]-1))
+			}
+		}
+	}
+	for cx := int64(0); cx < cols; cx++ {
+		for cy := int64(0); cy < rows; cy++ {
+			i := cx*rows + cy
+			if cx+1 < cols {
+				j := i + rows
+				join(summaries[i].edges[edgeRight], offsets[i], summaries[j].edges[edgeLeft], offsets[j])
+			}
+			if cy+1 < rows {
+				j := i + 1
+				join(summaries[i].edges[edgeBottom], offsets[i], summaries[j].edges[edgeTop], offsets[j])
+			}
+		}
+	}
+	for i := range regions {
+		root := find(int32(i))
+		if root == int32(i) {
+			continue
+		}
+		regions[root].size += regions[i].size
+		regions[root].owner = mergeOwner(regions[root].owner, regions[i].owner)
+		regions[root].open = regions[root].open || regions[i].open
+	}
+
+	score := Score{Region: area, ServerSeq: r.Seq}
+	territory := make(map[Color]int)
+	for i, region := range regions {
+		if find(int32(i)) != int32(i) || region.open {
+			continue
+		}
+		switch {
+		case region.owner >= 0:
+			territory[Color(region.owner)] += region.size
+		case region.owner == ownerMixed:
+			score.Neutral += region.size
+		}
+	}
+	for color, n := range stones {
+		score.Colors = append(score.Colors, ColorScore{Color: color, Stones: n})
+	}
+	// Territory is always bordered by stones inside the area, so every
+	// owner already has an entry
+	for i := range score.Colors {
+		cs := &score.Colors[i]
+		cs.Territory = territory[cs.Color]
+		cs.Score = cs.Stones + cs.Territory
+	}
+	sort.Slice(score.Colors, func(i, j int) bool {
+		a, b := score.Colors[i], score.Colors[j]
+		return a.Score > b.Score || (a.Score == b.Score && a.Color < b.Color)
+	})
+	return score, nil
+}
+
+func (r *Room) serveScore(req ScoreRequest) {
+	score, err := r.ScoreRegion(req.Region)
+	if req.Reply != nil {
+		req.Reply <- ScoreReply{Score: score, Err: err}
+		return
+	}
+	if req.Player == nil {
+		return
+	}
+	if err != nil {
+		req.Player.sendError(err.Error())
+		return
+	}
+	req.Player.sendEnvelope(Envelope{Type: "score", Score: &score})
+}
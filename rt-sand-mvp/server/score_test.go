@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ring places a hollow square of color with corners (x0,y0) and (x1,y1)
+func ring(t *testing.T, room *Room, x0, y0, x1, y1 int64, color Color) {
+	t.Helper()
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			if x != x0 && x != x1 && y != y0 && y != y1 {
+				continue
+			}
+			if res := room.ProcessMove(MoveRequest{X: x, Y: y, Color: color}); !res.Accepted {
+				t.Fatalf("setup move (%d,%d) rejected: %s", x, y, res.Reason)
+			}
+		}
+	}
+}
+
+func scoreOf(score Score, color Color) ColorScore {
+	for _, cs := range score.Colors {
+		if cs.Color == color {
+			return cs
+		}
+	}
+	return ColorScore{Color: color}
+}
+
+func TestScoreEnclosedTerritory(t *testing.T) {
+	room := NewRoom()
+	// A black ring crossing the chunk border at x=512 encloses 3x3 points
+	ring(t, room, 510, -2, 514, 2, ColorBlack)
+	// A white stone far away leaves the space between them open
+	room.ProcessMove(MoveRequest{X: 600, Y: 40, Color: ColorWhite})
+
+	score, err := room.ScoreRegion(nil)
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if score.Region != (Rect{MinX: 510, MinY: -2, MaxX: 600, MaxY: 40}) {
+		t.Fatalf("unexpected bounding box %+v", score.Region)
+	}
+	black := scoreOf(score, ColorBlack)
+	if black.Stones != 16 || black.Territory != 9 || black.Score != 25 {
+		t.Fatalf("unexpected black score %+v", black)
+	}
+	if white := scoreOf(score, ColorWhite); white.Score != 1 || score.Colors[0].Color != ColorBlack {
+		t.Fatalf("unexpected ranking %+v", score.Colors)
+	}
+
+	// A white stone inside turns the enclosed area neutral
+	room.ProcessMove(MoveRequest{X: 512, Y: 0, Color: ColorWhite})
+	score, _ = room.ScoreRegion(nil)
+	if black := scoreOf(score, ColorBlack); black.Territory != 0 || score.Neutral != 8 {
+		t.Fatalf("expected 8 neutral points, got %+v neutral=%d", black, score.Neutral)
+	}
+}
+
+func TestScoreRegionEdgesAreOpen(t *testing.T) {
+	room := NewRoom()
+	ring(t, room, 0, 0, 4, 4, ColorRed)
+
+	// Cutting through the ring leaves its inside open to the outside
+	score, err := room.ScoreRegion(&Rect{MinX: 2, MinY: 0, MaxX: 10, MaxY: 4})
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if red := scoreOf(score, ColorRed); red.Territory != 0 || red.Stones != 9 {
+		t.Fatalf("unexpected red score %+v", red)
+	}
+	if _, err := room.ScoreRegion(&Rect{MaxX: 100 * ChunkSize, MaxY: 100 * ChunkSize}); err != ErrRegionTooLarge {
+		t.Fatalf("expected ErrRegionTooLarge, got %v", err)
+	}
+}
+
+func TestScoreReusesUnchangedChunks(t *testing.T) {
+	room := NewRoom()
+	ring(t, room, -3, -3, 3, 3, ColorBlue)
+	ring(t, room, 1000, 0, 1004, 4, ColorGreen)
+	area := &Rect{MinX: -10, MinY: -10, MaxX: 1010, MaxY: 10}
+	if _, err := room.ScoreRegion(area); err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	green := room.scoreCache[ChunkID{X: 1, Y: 0}].summary
+
+	room.ProcessMove(MoveRequest{X: 0, Y: 0, Color: ColorBlue})
+	score, _ := room.ScoreRegion(area)
+	if room.scoreCache[ChunkID{X: 1, Y: 0}].summary != green {
+		t.Fatalf("unchanged chunk was scored again")
+	}
+	if blue := scoreOf(score, ColorBlue); blue.Stones != 25 || blue.Territory != 24 {
+		t.Fatalf("stale score after a move: %+v", blue)
+	}
+	if g := scoreOf(score, ColorGreen); g.Territory != 9 {
+		t.Fatalf("unexpected green score %+v", g)
+	}
+}
+
+func TestScoreEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rm := NewRoomManager(ctx, nil, DefaultRoomConfig())
+	room, _ := rm.CreateRoom("score", RoomSettings{})
+	// The room goroutine is idle, and sending to ScoreInbox orders these
+	// moves before the request
+	ring(t, room, 0, 0, 2, 2, ColorYellow)
+
+	rec := httptest.NewRecorder()
+	ServeRoomAPI(rm, rec, httptest.NewRequest(http.MethodGet, "/api/rooms/score/score", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var score Score
+	if err := json.Unmarshal(rec.Body.Bytes(), &score); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if y := scoreOf(score, ColorYellow); y.Score != 9 {
+		t.Fatalf("unexpected score %+v", score)
+	}
+
+	rec = httptest.NewRecorder()
+	ServeRoomAPI(rm, rec, httptest.NewRequest(http.MethodGet, "/api/rooms/score/score?min_x=a", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad rectangle, got %d", rec.Code)
+	}
+}
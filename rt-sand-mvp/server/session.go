@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrColorTaken is returned when a color is held by another session
+var ErrColorTaken = errors.New("color_taken")
+
+// ErrColorNotHeld is sent for moves and restarts in a color the sender's
+// session does not hold
+const ErrColorNotHeld = "color_not_held"
+
+// DefaultSeatGrace is how long a disconnected player keeps their color
+const DefaultSeatGrace = 2 * time.Minute
+
+// PaletteSize is the number of named colors, ColorBlack to ColorPink. Free
+// colors are offered from these.
+const PaletteSize = int(ColorPink) + 1
+
+// ColorList is a list of colors that marshals as numbers; a plain []uint8
+// would be base64
+type ColorList []Color
+
+func (l ColorList) MarshalJSON() ([]byte, error) {
+	colors := make([]int, len(l))
+	for i, c := range l {
+		colors[i] = int(c)
+	}
+	return json.Marshal(colors)
+}
+
+// Seat is a color held by one player session in a room. Seats outlive
+// connections, so a player who reloads the page gets the color back.
+type Seat struct {
+	Color       Color     `json:"color"`
+	SessionID   string    `json:"session_id"`
+	ConnectedAt time.Time `json:"connected_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	Connected   bool      `json:"connected"`
+
+	conns int // open connections of the session, owned by the room goroutine
+}
+
+// Session tells a client its token and, when it holds a seat, its color
+// and that color's counters
+type Session struct {
+	Token string      `json:"token"`
+	Color *Color      `json:"color,omitempty"`
+	Stats *ColorStats `json:"stats,omitempty"`
+}
+
+// Session request kinds
+const (
+	sessionJoin  = "join"
+	sessionClaim = "claim"
+	sessionLeave = "leave"
+)
+
+// SessionRequest asks the room goroutine to join, claim a color for, or
+// leave a session. Join and claim answer on Reply.
+type SessionRequest struct {
+	Kind   string
+	Player *Client
+	Token  string
+	Color  Color // for claims
+	Reply  chan SessionReply
+}
+
+type SessionReply struct {
+	Session    Session
+	Err        error
+	FreeColors ColorList // with ErrColorTaken
+}
+
+// sessionTokenBytes is the entropy of a session token; tokens are sent as hex
+const sessionTokenBytes = 16
+
+func newSessionToken() string {
+	b := make([]byte, sessionTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// processSessionSecret signs session tokens when no secret is configured,
+// so they then only last until the server restarts
+var processSessionSecret = newSessionToken()
+
+// issueSessionToken mints a session token: a random ID followed by its
+// signature, so clients cannot choose their own
+func (r *Room) issueSessionToken() string {
+	id := newSessionToken()
+	return id + r.signSession(id)
+}
+
+// signSession is the hex HMAC of a session ID, cut to the ID's length
+func (r *Room) signSession(id string) string {
+	secret := r.Config.SessionSecret
+	if secret == "" {
+		secret = processSessionSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:sessionTokenBytes])
+}
+
+// knownSession reports whether the server issued token. Tokens are kept
+// across rooms, so any validly signed one is accepted. Tokens signed before
+// a restart with a random secret, or from before tokens were signed, are
+// accepted while they hold a seat here. It may be called from any
+// goroutine.
+func (r *Room) knownSession(token string) bool {
+	if len(token) == 4*sessionTokenBytes {
+		id, sig := token[:2*sessionTokenBytes], token[2*sessionTokenBytes:]
+		if hmac.Equal([]byte(sig), []byte(r.signSession(id))) {
+			return true
+		}
+	}
+	r.clMu.RLock()
+	defer r.clMu.RUnlock()
+	_, seated := r.seated[token]
+	return token != "" && seated
+}
+
+// requestSession sends a request to the room goroutine and waits for the
+// answer. It fails when the connection or the room goes away first.
+func (c *Client) requestSession(ctx context.Context, req SessionRequest) (SessionReply, bool) {
+	req.Reply = make(chan SessionReply, 1)
+	select {
+	case c.room.SessionInbox <- req:
+	case <-ctx.Done():
+		return SessionReply{}, false
+	case <-c.room.done:
+		return SessionReply{}, false
+	}
+	select {
+	case reply := <-req.Reply:
+		return reply, true
+	case <-ctx.Done():
+		return SessionReply{}, false
+	case <-c.room.done:
+		return SessionReply{}, false
+	}
+}
+
+// leaveSession tells the room that a connection of the session closed
+func (c *Client) leaveSession() {
+	if c.session == "" {
+		return
+	}
+	select {
+	case c.room.SessionInbox <- SessionRequest{Kind: sessionLeave, Player: c, Token: c.session}:
+	case <-c.room.done:
+	}
+}
+
+func (r *Room) seatOf(token string) *Seat {
+	for _, seat := range r.seats {
+		if seat.SessionID == token {
+			return seat
+		}
+	}
+	return nil
+}
+
+// holdsSeat reports whether the session of a connection holds color. A
+// client's selectedColor is only a hint; the seats decide. Requests without
+// a connection come from the server itself and are always allowed.
+func (r *Room) holdsSeat(c *Client, color Color) bool {
+	if c == nil {
+		return true
+	}
+	seat, ok := r.seats[color]
+	return ok && seat.SessionID == r.conns[c]
+}
+
+func (r *Room) serveSession(req SessionRequest) {
+	now := time.Now()
+	var reply SessionReply
+	switch req.Kind {
+	case sessionJoin:
+		reply.Session = r.joinSession(req.Token, now)
+		if req.Player != nil {
+			if r.conns == nil {
+				r.conns = make(map[*Client]string)
+			}
+			r.conns[req.Player] = reply.Session.Token
+			// The connection now takes its session's player slot
+			r.clMu.Lock()
+			if _, ok := r.clients[req.Player]; ok {
+				r.clients[req.Player] = reply.Session.Token
+			}
+			r.clMu.Unlock()
+		}
+	case sessionClaim:
+		reply.Err = r.claimColor(req.Token, req.Color, now)
+		reply.Session = r.sessionInfo(req.Token)
+		if reply.Err != nil {
+			reply.FreeColors = r.freeColors()
+		}
+	case sessionLeave:
+		delete(r.conns, req.Player)
+		r.leaveSession(req.Token, now)
+		if r.Config.SeatGrace <= 0 {
+			r.releaseSeats(now)
+		}
+	}
+	if req.Reply != nil {
+		req.Reply <- reply
+	}
+}
+
+// joinSession connects a session, issuing a token if it has none or one
+// the server never issued, and returns its seat if it holds one
+func (r *Room) joinSession(token string, now time.Time) Session {
+	if !r.knownSession(token) {
+		token = r.issueSessionToken()
+	}
+	if seat := r.seatOf(token); seat != nil {
+		seat.conns++
+		seat.Connected = true
+		seat.ConnectedAt = now
+		seat.LastSeenAt = now
+		r.seatsChanged()
+	}
+	return r.sessionInfo(token)
+}
+
+func (r *Room) sessionInfo(token string) Session {
+	session := Session{Token: token}
+	if seat := r.seatOf(token); seat != nil {
+		color := seat.Color
+		session.Color = &color
+		if s, ok := r.stats[color]; ok {
+			stats := *s
+			session.Stats = &stats
+		}
+	}
+	return session
+}
+
+// claimColor gives a color to a session, freeing the one it held before
+func (r *Room) claimColor(token string, color Color, now time.Time) error {
+	if seat, ok := r.seats[color]; ok {
+		if seat.SessionID != token {
+			return ErrColorTaken
+		}
+		return nil
+	}
+	if old := r.seatOf(token); old != nil {
+		delete(r.seats, old.Color)
+	}
+	if r.seats == nil {
+		r.seats = make(map[Color]*Seat)
+	}
+	r.seats[color] = &Seat{
+		Color:       color,
+		SessionID:   token,
+		ConnectedAt: now,
+		LastSeenAt:  now,
+		Connected:   true,
+		conns:       r.sessionConns(token),
+	}
+	r.seatsChanged()
+	return nil
+}
+
+// sessionConns counts the joined connections of a session
+func (r *Room) sessionConns(token string) int {
+	n := 0
+	for _, session := range r.conns {
+		if session == token {
+			n++
+		}
+	}
+	return n
+}
+
+// leaveSession marks one connection of a session closed. The seat stays
+// with the session for the grace period, see releaseSeats.
+func (r *Room) leaveSession(token string, now time.Time) {
+	seat := r.seatOf(token)
+	if seat == nil {
+		return
+	}
+	if seat.conns > 0 {
+		seat.conns--
+	}
+	seat.Connected = seat.conns > 0
+	seat.LastSeenAt = now
+	r.seatsChanged()
+}
+
+// releaseSeats frees the colors of sessions that have been gone for longer
+// than the grace period
+func (r *Room) releaseSeats(now time.Time) {
+	released := false
+	for color, seat := range r.seats {
+		if !seat.Connected && now.Sub(seat.LastSeenAt) >= r.Config.SeatGrace {
+			delete(r.seats, color)
+			released = true
+		}
+	}
+	if released {
+		r.seatsChanged()
+	}
+}
+
+// seatSweepInterval is how often releaseSeats runs for a grace period
+func seatSweepInterval(grace time.Duration) time.Duration {
+	if d := grace / 4; d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// freeColors lists the palette colors nobody holds
+func (r *Room) freeColors() ColorList {
+	free := make(ColorList, 0, PaletteSize)
+	for c := 0; c < PaletteSize; c++ {
+		if _, ok := r.seats[Color(c)]; !ok {
+			free = append(free, Color(c))
+		}
+	}
+	return free
+}
+
+func (r *Room) seatsChanged() {
+	r.publishSeats()
+	if r.store != nil {
+		r.seatsDirty = true
+	}
+}
+
+// publishSeats copies the held colors and their sessions for Info and the
+// capacity check, which run on other goroutines
+func (r *Room) publishSeats() {
+	taken := make(ColorList, 0, len(r.seats))
+	for color := range r.seats {
+		taken = append(taken, color)
+	}
+	sort.Slice(taken, func(i, j int) bool { return taken[i] < taken[j] })
+	seated := make(map[string]struct{}, len(r.seats))
+	for _, seat := range r.seats {
+		seated[seat.SessionID] = struct{}{}
+	}
+	r.clMu.Lock()
+	r.taken = taken
+	r.seated = seated
+	r.clMu.Unlock()
+}
+
+// copySeats returns the seats ordered by color
+func (r *Room) copySeats() []Seat {
+	seats := make([]Seat, 0, len(r.seats))
+	for _, seat := range r.seats {
+		seats = append(seats, *seat)
+	}
+	sort.Slice(seats, func(i, j int) bool { return seats[i].Color < seats[j].Color })
+	return seats
+}
+
+// restoreSeats loads saved seats; nobody is connected after a restart
+func (r *Room) restoreSeats(seats []Seat) {
+	r.seats = make(map[Color]*Seat, len(seats))
+	for _, seat := range seats {
+		seat := seat
+		seat.Connected = false
+		seat.conns = 0
+		r.seats[seat.Color] = &seat
+	}
+	r.publishSeats()
+}
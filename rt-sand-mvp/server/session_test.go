@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestColorOwnership(t *testing.T) {
+	room := NewRoom()
+	now := time.Now()
+	alice := room.joinSession("", now).Token
+	bob := room.joinSession("", now).Token
+	if alice == bob || !room.knownSession(alice) {
+		t.Fatalf("expected two fresh tokens, got %q and %q", alice, bob)
+	}
+
+	if err := room.claimColor(alice, ColorRed, now); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if err := room.claimColor(bob, ColorRed, now); err != ErrColorTaken {
+		t.Fatalf("expected color_taken, got %v", err)
+	}
+	// Switching colors frees the old one
+	if err := room.claimColor(alice, ColorBlue, now); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if err := room.claimColor(bob, ColorRed, now); err != nil {
+		t.Fatalf("freed color should be claimable: %v", err)
+	}
+}
+
+func TestSessionTokensAreIssued(t *testing.T) {
+	room := NewRoom()
+	now := time.Now()
+	chosen := newSessionToken()
+	if got := room.joinSession(chosen, now).Token; got == chosen || !room.knownSession(got) {
+		t.Fatalf("a made-up token was adopted: %q", got)
+	}
+	issued := room.joinSession("", now).Token
+	if got := room.joinSession(issued, now).Token; got != issued {
+		t.Fatalf("issued token not resumed, got %q", got)
+	}
+	// Tokens carry over to other rooms of the same server
+	if got := NewRoom().joinSession(issued, now).Token; got != issued {
+		t.Fatalf("token not accepted by another room, got %q", got)
+	}
+	forged := issued[:len(issued)-1] + "0"
+	if forged == issued {
+		forged = issued[:len(issued)-1] + "1"
+	}
+	if room.knownSession(forged) {
+		t.Fatalf("a token with a bad signature was accepted")
+	}
+}
+
+func TestSessionResumesSeat(t *testing.T) {
+	room := NewRoom()
+	now := time.Now()
+	token := room.joinSession("", now).Token
+	room.claimColor(token, ColorGreen, now)
+	room.ProcessMove(MoveRequest{X: 1, Y: 1, Color: ColorGreen})
+	room.leaveSession(token, now)
+	if room.seats[ColorGreen].Connected {
+		t.Fatalf("seat should be disconnected")
+	}
+
+	session := room.joinSession(token, now)
+	if session.Token != token || session.Color == nil || *session.Color != ColorGreen {
+		t.Fatalf("expected the green seat back, got %+v", session)
+	}
+	if session.Stats == nil || session.Stats.Stones != 1 || !room.seats[ColorGreen].Connected {
+		t.Fatalf("unexpected stats %+v", session.Stats)
+	}
+}
+
+func TestSeatsSurviveRecovery(t *testing.T) {
+	store := NewMemoryStore()
+	original := NewRoom()
+	if err := original.AttachStore("seats", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	token := original.joinSession("", time.Now()).Token
+	original.claimColor(token, ColorCyan, time.Now())
+	if err := original.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("seats", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	seat, ok := rebuilt.seats[ColorCyan]
+	if !ok || seat.SessionID != token || seat.Connected {
+		t.Fatalf("unexpected seat after recovery %+v", seat)
+	}
+}
+
+func TestWebSocketReconnectKeepsColor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rm := NewRoomManager(ctx, nil, DefaultRoomConfig())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWS(rm, w, r)
+	}))
+	defer srv.Close()
+	rm.CreateRoom("seat", RoomSettings{})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=seat"
+
+	// dial connects and returns the session the server sent
+	dial := func(query string) (*websocket.Conn, Session) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var env Envelope
+			if err := conn.ReadJSON(&env); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if env.Type == "session" {
+				return conn, *env.Session
+			}
+		}
+	}
+
+	first, session := dial("")
+	first.WriteJSON(map[string]interface{}{"type": "select_color", "color": int(ColorOrange)})
+	var env Envelope
+	if err := first.ReadJSON(&env); err != nil || env.Type != "color_selected" {
+		t.Fatalf("expected color_selected, got %+v: %v", env, err)
+	}
+	first.Close()
+
+	// Another player cannot take the color while the seat is held
+	other, _ := dial("")
+	defer other.Close()
+	other.WriteJSON(map[string]interface{}{"type": "select_color", "color": int(ColorOrange)})
+	if err := other.ReadJSON(&env); err != nil || env.MoveResult == nil || env.MoveResult.Reason != "color_taken" {
+		t.Fatalf("expected color_taken, got %+v: %v", env, err)
+	}
+
+	again, resumed := dial("&session=" + session.Token)
+	defer again.Close()
+	if resumed.Token != session.Token || resumed.Color == nil || *resumed.Color != ColorOrange {
+		t.Fatalf("expected the orange seat back, got %+v", resumed)
+	}
+}
+
+func TestSeatReleasedAfterGrace(t *testing.T) {
+	room := NewRoom()
+	room.Config.SeatGrace = time.Minute
+	now := time.Now()
+	token := room.joinSession("", now).Token
+	room.claimColor(token, ColorYellow, now)
+	if taken := room.Info().TakenColors; len(taken) != 1 || taken[0] != ColorYellow {
+		t.Fatalf("expected yellow taken, got %v", taken)
+	}
+	room.leaveSession(token, now)
+
+	room.releaseSeats(now.Add(30 * time.Second))
+	if _, ok := room.seats[ColorYellow]; !ok {
+		t.Fatalf("seat released within the grace period")
+	}
+	room.releaseSeats(now.Add(time.Minute))
+	if _, ok := room.seats[ColorYellow]; ok || len(room.Info().TakenColors) != 0 {
+		t.Fatalf("seat should be released after the grace period")
+	}
+}
+
+func TestColorTakenOffersFreeColors(t *testing.T) {
+	room := NewRoom()
+	room.Config.SeatGrace = 0
+	now := time.Now()
+	holder := room.joinSession("", now).Token
+	room.claimColor(holder, ColorBlack, now)
+
+	reply := make(chan SessionReply, 1)
+	room.serveSession(SessionRequest{Kind: sessionClaim, Token: newSessionToken(), Color: ColorBlack, Reply: reply})
+	got := <-reply
+	if got.Err != ErrColorTaken || len(got.FreeColors) != PaletteSize-1 || got.FreeColors[0] != ColorWhite {
+		t.Fatalf("expected the other palette colors, got %+v", got)
+	}
+
+	// Without a grace period the color is free as soon as its holder leaves
+	room.serveSession(SessionRequest{Kind: sessionLeave, Token: holder})
+	if len(room.seats) != 0 {
+		t.Fatalf("seat should be released immediately")
+	}
+}
+
+// joinTab joins a connection to a session through the room's session
+// handling, as readPump does
+func joinTab(room *Room, c *Client, token string) string {
+	reply := make(chan SessionReply, 1)
+	room.serveSession(SessionRequest{Kind: sessionJoin, Player: c, Token: token, Reply: reply})
+	return (<-reply).Session.Token
+}
+
+func TestOnlySeatHolderPlaysColor(t *testing.T) {
+	room := NewRoom()
+	now := time.Now()
+	tabA, tabB := &Client{}, &Client{}
+	token := joinTab(room, tabA, "")
+	joinTab(room, tabB, token)
+	room.claimColor(token, ColorBlue, now)
+	if !room.holdsSeat(tabA, ColorBlue) || !room.holdsSeat(tabB, ColorBlue) {
+		t.Fatalf("both tabs of the session should hold blue")
+	}
+
+	// One tab switches; the other still thinks it is blue
+	room.claimColor(token, ColorRed, now)
+	if room.holdsSeat(tabB, ColorBlue) {
+		t.Fatalf("a freed color should not be playable")
+	}
+	other := &Client{}
+	room.claimColor(joinTab(room, other, ""), ColorBlue, now)
+	if room.holdsSeat(tabB, ColorBlue) || !room.holdsSeat(other, ColorBlue) {
+		t.Fatalf("only the new holder should play blue")
+	}
+}
+
+func TestSeatKeptWhileAnotherTabIsOpen(t *testing.T) {
+	room := NewRoom()
+	room.Config.SeatGrace = 0
+	tabA, tabB := &Client{}, &Client{}
+	token := joinTab(room, tabA, "")
+	joinTab(room, tabB, token)
+	room.claimColor(token, ColorPurple, time.Now())
+
+	// Closing one tab must not free the color the other is playing
+	room.serveSession(SessionRequest{Kind: sessionLeave, Player: tabA, Token: token})
+	seat, ok := room.seats[ColorPurple]
+	if !ok || !seat.Connected || !room.holdsSeat(tabB, ColorPurple) {
+		t.Fatalf("seat should stay with the open tab, got %+v", seat)
+	}
+	room.serveSession(SessionRequest{Kind: sessionLeave, Player: tabB, Token: token})
+	if _, ok := room.seats[ColorPurple]; ok {
+		t.Fatalf("seat should be released once every tab is closed")
+	}
+}
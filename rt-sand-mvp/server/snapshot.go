@@ -0,0 +1,134 @@
+package server
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy thins old snapshots so long-lived boards keep a bounded
+// number of them: everything recent, then one per hour, then one per day.
+type RetentionPolicy struct {
+	KeepAll    time.Duration // keep every snapshot younger than this
+	KeepHourly time.Duration // keep one snapshot per hour up to this age
+}
+
+// DefaultRetentionPolicy keeps an hour of snapshots, hourly ones for a day
+// and daily ones forever
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		KeepAll:    time.Hour,
+		KeepHourly: 24 * time.Hour,
+	}
+}
+
+// Expired returns the sequence numbers of snapshots the policy drops. The
+// newest snapshot of every hourly or daily bucket is kept, as is the newest
+// snapshot overall.
+func (p RetentionPolicy) Expired(snaps []Snapshot, now time.Time) []uint64 {
+	sorted := make([]Snapshot, len(snaps))
+	copy(sorted, snaps)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ServerSeq > sorted[j].ServerSeq })
+
+	var expired []uint64
+	seen := make(map[time.Time]struct{})
+	for i, snap := range sorted {
+		age := now.Sub(snap.CreatedAt)
+		if age < p.KeepAll {
+			continue
+		}
+		bucket := snap.CreatedAt.Truncate(24 * time.Hour)
+		if age < p.KeepHourly {
+			bucket = snap.CreatedAt.Truncate(time.Hour)
+		}
+		if _, ok := seen[bucket]; ok && i > 0 {
+			expired = append(expired, snap.ServerSeq)
+			continue
+		}
+		seen[bucket] = struct{}{}
+	}
+	return expired
+}
+
+// snapshotJob is a copy of the board handed to the snapshot worker
+type snapshotJob struct {
+	chunks map[ChunkID]*Chunk
+	stats  []ColorStats
+	seq    uint64
+}
+
+// takeSnapshotJob copies the board; encoding happens on the worker
+func (r *Room) takeSnapshotJob() snapshotJob {
+	chunks := make(map[ChunkID]*Chunk, len(r.Chunks))
+	for id, ch := range r.Chunks {
+		chunks[id] = ch.clone()
+	}
+	return snapshotJob{chunks: chunks, stats: r.copyStats(), seq: r.Seq}
+}
+
+// requestSnapshot hands a snapshot to the worker if it is idle, reporting
+// whether it was accepted
+func (r *Room) requestSnapshot(queue chan<- snapshotJob) bool {
+	select {
+	case queue <- r.takeSnapshotJob():
+		r.changesSinceSnapshot = 0
+		return true
+	default:
+		return false
+	}
+}
+
+// noteChange counts a board change and snapshots once enough have piled up.
+// It runs before the change is broadcast.
+func (r *Room) noteChange(queue chan<- snapshotJob) {
+	if queue == nil {
+		return
+	}
+	if r.journalGap {
+		// The journal is missing an entry; snapshot right away so recovery
+		// starts after it. Retried on the next change if this fails too.
+		if err := r.saveSnapshot(); err != nil {
+			log.Printf("room %s: snapshot after journal failure: %v", r.ID, err)
+		} else {
+			r.journalGap = false
+			r.changesSinceSnapshot = 0
+		}
+		return
+	}
+	r.changesSinceSnapshot++
+	if r.Config.SnapshotEvery > 0 && r.changesSinceSnapshot >= r.Config.SnapshotEvery {
+		r.requestSnapshot(queue)
+	}
+}
+
+// snapshotWorker encodes and writes snapshots off the room goroutine and
+// applies the retention policy after each one.
+func (r *Room) snapshotWorker(queue <-chan snapshotJob, done chan<- struct{}) {
+	defer close(done)
+	for job := range queue {
+		data, err := encodeSnapshotChunks(job.chunks, job.stats, job.seq)
+		if err != nil {
+			log.Printf("room %s: encode snapshot: %v", r.ID, err)
+			continue
+		}
+		now := time.Now()
+		if err := r.store.SaveSnapshot(r.ID, Snapshot{ServerSeq: job.seq, Data: data, CreatedAt: now}); err != nil {
+			log.Printf("room %s: save snapshot %d: %v", r.ID, job.seq, err)
+			continue
+		}
+		r.pruneSnapshots(now)
+	}
+}
+
+func (r *Room) pruneSnapshots(now time.Time) {
+	snaps, err := r.store.ListSnapshots(r.ID)
+	if err != nil {
+		log.Printf("room %s: list snapshots: %v", r.ID, err)
+		return
+	}
+	for _, seq := range r.Config.Retention.Expired(snaps, now) {
+		if err := r.store.DeleteSnapshot(r.ID, seq); err != nil {
+			log.Printf("room %s: delete snapshot %d: %v", r.ID, seq, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyThinsOldSnapshots(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	policy := RetentionPolicy{KeepAll: time.Hour, KeepHourly: 24 * time.Hour}
+	snaps := []Snapshot{
+		{ServerSeq: 100, CreatedAt: now.Add(-10 * time.Minute)}, // recent
+		{ServerSeq: 90, CreatedAt: now.Add(-40 * time.Minute)},  // recent
+		{ServerSeq: 80, CreatedAt: now.Add(-2*time.Hour - 5*time.Minute)},
+		{ServerSeq: 70, CreatedAt: now.Add(-2*time.Hour - 20*time.Minute)}, // same hour as 80
+		{ServerSeq: 60, CreatedAt: now.Add(-5 * time.Hour)},
+		{ServerSeq: 20, CreatedAt: now.Add(-72 * time.Hour)},
+		{ServerSeq: 10, CreatedAt: now.Add(-72*time.Hour - time.Hour)}, // same day as 20
+		{ServerSeq: 5, CreatedAt: now.Add(-96 * time.Hour)},
+	}
+
+	expired := policy.Expired(snaps, now)
+	want := map[uint64]bool{70: true, 10: true}
+	if len(expired) != len(want) {
+		t.Fatalf("expected %d expired snapshots, got %v", len(want), expired)
+	}
+	for _, seq := range expired {
+		if !want[seq] {
+			t.Fatalf("snapshot %d should have been kept", seq)
+		}
+	}
+}
+
+func TestRetentionKeepsNewestSnapshot(t *testing.T) {
+	now := time.Now()
+	snaps := []Snapshot{
+		{ServerSeq: 2, CreatedAt: now.Add(-48 * time.Hour)},
+		{ServerSeq: 1, CreatedAt: now.Add(-48*time.Hour - time.Minute)},
+	}
+	expired := DefaultRetentionPolicy().Expired(snaps, now)
+	if len(expired) != 1 || expired[0] != 1 {
+		t.Fatalf("expected only seq 1 to expire, got %v", expired)
+	}
+}
+
+func TestRunSnapshotsEveryNMoves(t *testing.T) {
+	store := NewMemoryStore()
+	room := NewRoom()
+	room.Config.SnapshotEvery = 5
+	room.Config.SnapshotInterval = 0
+	if err := room.AttachStore("snap", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		room.Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < 5; i++ {
+		room.Inbox <- MoveRequest{X: int64(i * 2), Y: 0, Color: ColorBlack}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		snap, err := store.LoadLatestSnapshot("snap")
+		if err == nil && snap.ServerSeq == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no snapshot at seq 5: %+v, %v", snap, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
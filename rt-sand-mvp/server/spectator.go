@@ -0,0 +1,21 @@
+package server
+
+// Connection roles, from the role query parameter of /ws
+const (
+	RolePlayer    = "player"
+	RoleSpectator = "spectator"
+)
+
+// ErrSpectator is sent to spectators that try to play
+const ErrSpectator = "spectator"
+
+// spectatorAllowed reports whether a spectator may send a message type.
+// Spectators can watch and page the board but not claim colors, move or
+// restart.
+func spectatorAllowed(msgType string) bool {
+	switch msgType {
+	case "get_state", "subscribe_region", "get_chunks", "get_score", "get_leaderboard":
+		return true
+	}
+	return false
+}
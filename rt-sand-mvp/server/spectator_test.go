@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSpectatorWatchesButCannotPlay(t *testing.T) {
+	rm, srv := adminServer(t, nil)
+	rm.CreateRoom("show", RoomSettings{})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?room=show"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"&role=referee", nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown role, got %v", err)
+	}
+	screen, _, err := websocket.DefaultDialer.Dial(url+"&role=spectator", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer screen.Close()
+	screen.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var env Envelope
+	if err := screen.ReadJSON(&env); err != nil || env.Type != "room_info" {
+		t.Fatalf("expected room_info, got %+v: %v", env, err)
+	}
+
+	for _, msg := range []map[string]interface{}{
+		{"type": "select_color", "color": int(ColorRed)},
+		{"type": "move", "x": 0, "y": 0, "color": int(ColorRed)},
+		{"type": "restart"},
+	} {
+		screen.WriteJSON(msg)
+		if err := screen.ReadJSON(&env); err != nil || env.MoveResult == nil || env.MoveResult.Reason != ErrSpectator {
+			t.Fatalf("%v: expected a spectator rejection, got %+v: %v", msg["type"], env, err)
+		}
+	}
+
+	player := joinWithColor(t, srv, "show", ColorRed)
+	room, _ := rm.GetRoom("show")
+	if info := room.Info(); info.PlayerCount != 1 || info.SpectatorCount != 1 {
+		t.Fatalf("expected 1 player and 1 spectator, got %+v", info)
+	}
+	player.WriteJSON(map[string]interface{}{"type": "move", "x": 2, "y": 3, "color": int(ColorRed)})
+	for {
+		if err := screen.ReadJSON(&env); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == "delta_update" {
+			break
+		}
+	}
+	if added := env.DeltaUpdate.Added; len(added) != 1 || added[0].X != 2 || added[0].Y != 3 {
+		t.Fatalf("unexpected delta %+v", env.DeltaUpdate)
+	}
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRoomNotFound     = errors.New("room not found")
+	ErrChunkNotFound    = errors.New("chunk not found")
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+// RoomRecord is the persisted metadata of a room
+type RoomRecord struct {
+	ID        string
+	ServerSeq uint64
+	Rules     *RuleSet // nil for rooms saved before rule sets existed
+	// MaxPlayers is the room's capacity; 0 for rooms saved before it was
+	// recorded, which get the configured default
+	MaxPlayers int
+	Access     *RoomAccess // nil for public rooms
+	// Set by POST /api/rooms; empty for rooms created by joining them
+	DisplayName string
+	Owner       string
+	CreatedAt   time.Time
+}
+
+// Journal entry kinds
+const (
+	JournalMove       = "move"
+	JournalResetColor = "reset_color"
+	JournalReset      = "reset"
+)
+
+// MoveRecord is one entry of a room's move journal. Resets do not advance
+// the sequence, so they carry the ServerSeq of the move before them.
+type MoveRecord struct {
+	Kind      string    `json:"kind"`
+	ServerSeq uint64    `json:"server_seq"`
+	PlayerID  string    `json:"player_id,omitempty"`
+	X         int64     `json:"x"`
+	Y         int64     `json:"y"`
+	Color     Color     `json:"color"`
+	Accepted  bool      `json:"accepted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Snapshot is a serialized copy of a whole board at a sequence number.
+// Data must be valid JSON so it fits the game_states jsonb column.
+type Snapshot struct {
+	ServerSeq uint64    `json:"server_seq"`
+	Data      []byte    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists room state so rooms survive a server restart
+type Store interface {
+	// LoadRoom returns the saved room record, or ErrRoomNotFound
+	LoadRoom(roomID string) (*RoomRecord, error)
+	// SaveRoom creates or updates the room record
+	SaveRoom(rec RoomRecord) error
+	// ListRooms returns every saved room record
+	ListRooms() ([]RoomRecord, error)
+	// LoadChunks returns every saved chunk of a room
+	LoadChunks(roomID string) ([]*Chunk, error)
+	// LoadChunk returns a single chunk, or ErrChunkNotFound
+	LoadChunk(roomID string, id ChunkID) (*Chunk, error)
+	// SaveChunk writes a chunk, deleting it when it holds no cells
+	SaveChunk(roomID string, ch *Chunk) error
+	// AppendMove adds an entry to the room's move journal
+	AppendMove(roomID string, mv MoveRecord) error
+	// LoadMoves returns journal entries with fromSeq <= ServerSeq <= toSeq
+	// in the order they were appended
+	LoadMoves(roomID string, fromSeq, toSeq uint64) ([]MoveRecord, error)
+	// SaveSnapshot stores a full board snapshot, replacing one at the same seq
+	SaveSnapshot(roomID string, snap Snapshot) error
+	// LoadLatestSnapshot returns the newest snapshot, or ErrSnapshotNotFound
+	LoadLatestSnapshot(roomID string) (*Snapshot, error)
+	// LoadSnapshotAt returns the newest snapshot at or before seq, or
+	// ErrSnapshotNotFound
+	LoadSnapshotAt(roomID string, seq uint64) (*Snapshot, error)
+	// ListSnapshots returns the seq and creation time of every snapshot,
+	// without their data
+	ListSnapshots(roomID string) ([]Snapshot, error)
+	// DeleteSnapshot removes the snapshot at seq
+	DeleteSnapshot(roomID string, seq uint64) error
+	// SaveStats writes the per-color counters of a room for other tools to
+	// read; rooms recover them from snapshots
+	SaveStats(roomID string, stats []ColorStats) error
+	// SaveSeats replaces the colors held by player sessions in a room
+	SaveSeats(roomID string, seats []Seat) error
+	// LoadSeats returns the saved seats of a room
+	LoadSeats(roomID string) ([]Seat, error)
+	// DeleteRoom removes a room and everything saved for it
+	DeleteRoom(roomID string) error
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// StoreConfig selects the storage backend
+type StoreConfig struct {
+	Backend string // "postgres", "file", "memory", or empty to try postgres
+	Dir     string // data directory of the file backend
+}
+
+// GetStoreConfig reads storage configuration from environment variables
+func GetStoreConfig() StoreConfig {
+	return StoreConfig{
+		Backend: getEnv("STORE_BACKEND", ""),
+		Dir:     getEnv("STORE_DIR", "data"),
+	}
+}
+
+// OpenStore opens the configured backend. With no backend configured it
+// tries PostgreSQL and falls back to memory when the database is unreachable.
+func OpenStore(cfg StoreConfig) (Store, error) {
+	switch cfg.Backend {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "file":
+		return NewFileStore(cfg.Dir)
+	case "postgres":
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+		return NewGormStore(DB), nil
+	case "":
+		if err := InitDB(); err != nil {
+			log.Printf("database unavailable, rooms will only be kept in memory: %v", err)
+			return NewMemoryStore(), nil
+		}
+		return NewGormStore(DB), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", cfg.Backend)
+	}
+}
+
+// MemoryStore is a Store kept entirely in process memory
+type MemoryStore struct {
+	mu        sync.RWMutex
+	rooms     map[string]RoomRecord
+	chunks    map[string]map[ChunkID]*Chunk
+	moves     map[string][]MoveRecord
+	snapshots map[string][]Snapshot
+	stats     map[string][]ColorStats
+	seats     map[string][]Seat
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		rooms:     make(map[string]RoomRecord),
+		chunks:    make(map[string]map[ChunkID]*Chunk),
+		moves:     make(map[string][]MoveRecord),
+		snapshots: make(map[string][]Snapshot),
+		stats:     make(map[string][]ColorStats),
+		seats:     make(map[string][]Seat),
+	}
+}
+
+func (s *MemoryStore) LoadRoom(roomID string) (*RoomRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.rooms[roomID]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return &rec, nil
+}
+
+func (s *MemoryStore) SaveRoom(rec RoomRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rooms[rec.ID] = rec
+	return nil
+}
+
+func (s *MemoryStore) ListRooms() ([]RoomRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	recs := make([]RoomRecord, 0, len(s.rooms))
+	for _, rec := range s.rooms {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	return recs, nil
+}
+
+func (s *MemoryStore) LoadChunks(roomID string) ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chunks := make([]*Chunk, 0, len(s.chunks[roomID]))
+	for _, ch := range s.chunks[roomID] {
+		chunks = append(chunks, ch.clone())
+	}
+	return chunks, nil
+}
+
+func (s *MemoryStore) LoadChunk(roomID string, id ChunkID) (*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ch, ok := s.chunks[roomID][id]
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	return ch.clone(), nil
+}
+
+func (s *MemoryStore) SaveChunk(roomID string, ch *Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := ChunkID{X: ch.X, Y: ch.Y}
+	if ch.len() == 0 {
+		delete(s.chunks[roomID], id)
+		return nil
+	}
+	if s.chunks[roomID] == nil {
+		s.chunks[roomID] = make(map[ChunkID]*Chunk)
+	}
+	s.chunks[roomID][id] = ch.clone()
+	return nil
+}
+
+func (s *MemoryStore) AppendMove(roomID string, mv MoveRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.moves[roomID] = append(s.moves[roomID], mv)
+	return nil
+}
+
+func (s *MemoryStore) LoadMoves(roomID string, fromSeq, toSeq uint64) ([]MoveRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var moves []MoveRecord
+	for _, mv := range s.moves[roomID] {
+		if mv.ServerSeq >= fromSeq && mv.ServerSeq <= toSeq {
+			moves = append(moves, mv)
+		}
+	}
+	return moves, nil
+}
+
+func (s *MemoryStore) SaveSnapshot(roomID string, snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := make([]byte, len(snap.Data))
+	copy(data, snap.Data)
+	snap.Data = data
+	for i, old := range s.snapshots[roomID] {
+		if old.ServerSeq == snap.ServerSeq {
+			s.snapshots[roomID][i] = snap
+			return nil
+		}
+	}
+	s.snapshots[roomID] = append(s.snapshots[roomID], snap)
+	return nil
+}
+
+func (s *MemoryStore) LoadLatestSnapshot(roomID string) (*Snapshot, error) {
+	return s.LoadSnapshotAt(roomID, math.MaxUint64)
+}
+
+func (s *MemoryStore) LoadSnapshotAt(roomID string, seq uint64) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var latest *Snapshot
+	for i, snap := range s.snapshots[roomID] {
+		if snap.ServerSeq > seq {
+			continue
+		}
+		if latest == nil || snap.ServerSeq > latest.ServerSeq {
+			latest = &s.snapshots[roomID][i]
+		}
+	}
+	if latest == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	snap := *latest
+	return &snap, nil
+}
+
+func (s *MemoryStore) ListSnapshots(roomID string) ([]Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snaps := make([]Snapshot, 0, len(s.snapshots[roomID]))
+	for _, snap := range s.snapshots[roomID] {
+		snaps = append(snaps, Snapshot{ServerSeq: snap.ServerSeq, CreatedAt: snap.CreatedAt})
+	}
+	return snaps, nil
+}
+
+func (s *MemoryStore) DeleteSnapshot(roomID string, seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snaps := s.snapshots[roomID]
+	for i, snap := range snaps {
+		if snap.ServerSeq == seq {
+			s.snapshots[roomID] = append(snaps[:i], snaps[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) SaveStats(roomID string, stats []ColorStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats[roomID] = append([]ColorStats(nil), stats...)
+	return nil
+}
+
+func (s *MemoryStore) SaveSeats(roomID string, seats []Seat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seats[roomID] = append([]Seat(nil), seats...)
+	return nil
+}
+
+func (s *MemoryStore) DeleteRoom(roomID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rooms, roomID)
+	delete(s.chunks, roomID)
+	delete(s.moves, roomID)
+	delete(s.snapshots, roomID)
+	delete(s.stats, roomID)
+	delete(s.seats, roomID)
+	return nil
+}
+
+func (s *MemoryStore) LoadSeats(roomID string) ([]Seat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Seat(nil), s.seats[roomID]...), nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	if _, err := store.LoadRoom("lan"); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("expected ErrRoomNotFound, got %v", err)
+	}
+	if err := store.SaveRoom(RoomRecord{ID: "lan", ServerSeq: 7}); err != nil {
+		t.Fatalf("save room: %v", err)
+	}
+	rec, err := store.LoadRoom("lan")
+	if err != nil || rec.ServerSeq != 7 {
+		t.Fatalf("load room: %+v, %v", rec, err)
+	}
+	recs, err := store.ListRooms()
+	if err != nil || len(recs) != 1 || recs[0].ID != "lan" {
+		t.Fatalf("list rooms: %+v, %v", recs, err)
+	}
+
+	ch := newChunkFromCells(-1, 2, map[uint32]Color{localIndex(3, 4): ColorRed})
+	if err := store.SaveChunk("lan", ch); err != nil {
+		t.Fatalf("save chunk: %v", err)
+	}
+	got, err := store.LoadChunk("lan", ChunkID{X: -1, Y: 2})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	if color, ok := got.get(localIndex(3, 4)); !ok || color != ColorRed || got.len() != 1 {
+		t.Fatalf("unexpected chunk cells: %+v", got.cellMap())
+	}
+	if chunks, err := store.LoadChunks("lan"); err != nil || len(chunks) != 1 {
+		t.Fatalf("load chunks: %d, %v", len(chunks), err)
+	}
+
+	if err := store.SaveChunk("lan", &Chunk{X: -1, Y: 2}); err != nil {
+		t.Fatalf("delete chunk: %v", err)
+	}
+	if _, err := store.LoadChunk("lan", ChunkID{X: -1, Y: 2}); !errors.Is(err, ErrChunkNotFound) {
+		t.Fatalf("expected ErrChunkNotFound, got %v", err)
+	}
+
+	for seq := uint64(8); seq <= 10; seq++ {
+		if err := store.AppendMove("lan", MoveRecord{ServerSeq: seq, X: 1, Y: 1, Accepted: true}); err != nil {
+			t.Fatalf("append move: %v", err)
+		}
+	}
+	if moves, err := store.LoadMoves("lan", 9, 9); err != nil || len(moves) != 1 || moves[0].ServerSeq != 9 {
+		t.Fatalf("load bounded moves: %+v, %v", moves, err)
+	}
+	if moves, err := store.LoadMoves("lan", 0, math.MaxUint64); err != nil || len(moves) != 3 {
+		t.Fatalf("load all moves: %+v, %v", moves, err)
+	}
+	if err := store.SaveSnapshot("lan", Snapshot{ServerSeq: 8, Data: []byte(`{}`)}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	testStore(t, store)
+}
+
+func TestFileStoreTornJournal(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	if err := store.AppendMove("torn", MoveRecord{ServerSeq: 1, Accepted: true}); err != nil {
+		t.Fatalf("append move: %v", err)
+	}
+	// A crash cut the second entry short, without its newline
+	f, err := os.OpenFile(filepath.Join(store.roomDir("torn"), "moves.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	f.WriteString(`{"server_seq":2,"x`)
+	f.Close()
+
+	if err := store.AppendMove("torn", MoveRecord{ServerSeq: 3, Accepted: true}); err != nil {
+		t.Fatalf("append after restart: %v", err)
+	}
+	moves, err := store.LoadMoves("torn", 0, math.MaxUint64)
+	if err != nil || len(moves) != 2 || moves[0].ServerSeq != 1 || moves[1].ServerSeq != 3 {
+		t.Fatalf("expected entries 1 and 3, got %+v, %v", moves, err)
+	}
+}
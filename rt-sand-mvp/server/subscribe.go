@@ -0,0 +1,117 @@
+package server
+
+import (
+	"errors"
+)
+
+// MaxSubscribedChunks caps how much of the board one client can watch
+const MaxSubscribedChunks = 64
+
+var (
+	ErrRegionTooLarge = errors.New("region_too_large")
+)
+
+type SubscribeRequest struct {
+	Player *Client
+	Chunks []ChunkID
+}
+
+// chunksInRect lists the chunks covering the cell rectangle [min, max]
+func chunksInRect(minX, minY, maxX, maxY int64) ([]ChunkID, error) {
+	if minX > maxX {
+		minX, maxX = maxX, minX
+	}
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+	lo, err := chunkIDFor(minX, minY)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := chunkIDFor(maxX, maxY)
+	if err != nil {
+		return nil, err
+	}
+	if (int64(hi.X)-int64(lo.X)+1)*(int64(hi.Y)-int64(lo.Y)+1) > MaxSubscribedChunks {
+		return nil, ErrRegionTooLarge
+	}
+	ids := make([]ChunkID, 0, (hi.X-lo.X+1)*(hi.Y-lo.Y+1))
+	for cx := lo.X; cx <= hi.X; cx++ {
+		for cy := lo.Y; cy <= hi.Y; cy++ {
+			ids = append(ids, ChunkID{X: cx, Y: cy})
+		}
+	}
+	return ids, nil
+}
+
+// subscribe replaces the client's subscription and returns the chunks it
+// was not already watching. Must be called on the room goroutine.
+func (r *Room) subscribe(c *Client, ids []ChunkID) []ChunkID {
+	next := make(map[ChunkID]struct{}, len(ids))
+	var added []ChunkID
+	for _, id := range ids {
+		if _, dup := next[id]; dup {
+			continue
+		}
+		next[id] = struct{}{}
+		if _, had := c.chunks[id]; !had {
+			added = append(added, id)
+		}
+	}
+	c.chunks = next
+	return added
+}
+
+// chunksState returns the cells of the given chunks as a scoped BoardState
+func (r *Room) chunksState(ids []ChunkID) BoardState {
+	scoped := make(map[ChunkID]*Chunk, len(ids))
+	for _, id := range ids {
+		if ch, ok := r.Chunks[id]; ok {
+			scoped[id] = ch
+		}
+	}
+	return BoardState{
+		Cells:     chunkCells(scoped),
+		Chunks:    ids,
+		ServerSeq: r.Seq,
+	}
+}
+
+// boardStateFor returns the part of the board a client is subscribed to,
+// or the whole board if it never subscribed
+func (r *Room) boardStateFor(c *Client) BoardState {
+	if c == nil || c.chunks == nil {
+		return r.GetBoardState()
+	}
+	ids := make([]ChunkID, 0, len(c.chunks))
+	for id := range c.chunks {
+		ids = append(ids, id)
+	}
+	return r.chunksState(ids)
+}
+
+// deltaChunks lists the chunks a delta touches
+func deltaChunks(delta DeltaUpdate) map[ChunkID]struct{} {
+	touched := make(map[ChunkID]struct{})
+	for _, cells := range [][]Cell{delta.Added, delta.Removed} {
+		for _, c := range cells {
+			if id, err := chunkIDFor(c.X, c.Y); err == nil {
+				touched[id] = struct{}{}
+			}
+		}
+	}
+	return touched
+}
+
+// wants reports whether a client subscribed to any of the touched chunks
+func (c *Client) wants(touched map[ChunkID]struct{}) bool {
+	if c.chunks == nil {
+		return true
+	}
+	for id := range touched {
+		if _, ok := c.chunks[id]; ok {
+			return true
+		}
+	}
+	return false
+}
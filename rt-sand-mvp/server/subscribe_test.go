@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChunksInRect(t *testing.T) {
+	ids, err := chunksInRect(-10, -10, 600, 10)
+	if err != nil {
+		t.Fatalf("chunks in rect: %v", err)
+	}
+	// x spans chunks -1..1, y spans chunks -1..0
+	if len(ids) != 6 {
+		t.Fatalf("expected 6 chunks, got %d: %v", len(ids), ids)
+	}
+	if _, err := chunksInRect(0, 0, 100*ChunkSize, 100*ChunkSize); err != ErrRegionTooLarge {
+		t.Fatalf("expected ErrRegionTooLarge, got %v", err)
+	}
+}
+
+func TestBroadcastOnlyReachesSubscribers(t *testing.T) {
+	room := NewRoom()
+	near := &Client{room: room, send: make(chan []byte, 4)}
+	far := &Client{room: room, send: make(chan []byte, 4)}
+	everything := &Client{room: room, send: make(chan []byte, 4)}
+	for _, c := range []*Client{near, far, everything} {
+		room.addClient(c)
+	}
+	room.subscribe(near, []ChunkID{{X: 0, Y: 0}})
+	room.subscribe(far, []ChunkID{{X: 10, Y: 10}})
+
+	room.broadcast(DeltaUpdate{Added: []Cell{{X: 3, Y: 4, Color: ColorRed}}, ServerSeq: 1})
+
+	if len(near.send) != 1 {
+		t.Fatalf("subscribed client should receive the delta")
+	}
+	if len(far.send) != 0 {
+		t.Fatalf("client watching another chunk should not receive the delta")
+	}
+	if len(everything.send) != 1 {
+		t.Fatalf("client without a subscription should receive every delta")
+	}
+}
+
+func TestSubscribeSendsOnlyNewChunks(t *testing.T) {
+	room := NewRoom()
+	room.ProcessMove(MoveRequest{X: 1, Y: 1, Color: ColorBlack})
+	room.ProcessMove(MoveRequest{X: ChunkSize + 1, Y: 1, Color: ColorWhite})
+	c := &Client{room: room, send: make(chan []byte, 4)}
+
+	added := room.subscribe(c, []ChunkID{{X: 0, Y: 0}})
+	state := room.chunksState(added)
+	if len(state.Cells) != 1 || state.Cells[0].Color != ColorBlack {
+		t.Fatalf("unexpected first state: %+v", state.Cells)
+	}
+
+	// Panning right keeps chunk (0,0) and adds (1,0)
+	added = room.subscribe(c, []ChunkID{{X: 0, Y: 0}, {X: 1, Y: 0}})
+	state = room.chunksState(added)
+	if len(state.Chunks) != 1 || state.Chunks[0] != (ChunkID{X: 1, Y: 0}) {
+		t.Fatalf("expected only chunk (1,0), got %v", state.Chunks)
+	}
+	if len(state.Cells) != 1 || state.Cells[0].Color != ColorWhite {
+		t.Fatalf("unexpected second state: %+v", state.Cells)
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded struct {
+		Chunks []map[string]int32 `json:"chunks"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil || decoded.Chunks[0]["x"] != 1 {
+		t.Fatalf("chunk IDs should encode as {x, y}: %s", payload)
+	}
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"time"
+)
+
+// Tick mode bounds for RuleSet.TickMS
+const (
+	MinTickMS = 50
+	MaxTickMS = 10000
+)
+
+// tick returns the tick length of a tick-mode room, zero otherwise
+func (rs RuleSet) tick() time.Duration {
+	return time.Duration(rs.TickMS) * time.Millisecond
+}
+
+// queueTickMove holds a move until the end of the current tick. Each client
+// gets one move per tick.
+func (r *Room) queueTickMove(req MoveRequest) (MoveResult, bool) {
+	if req.Player != nil {
+		for _, m := range r.tickMoves {
+			if m.Player == req.Player {
+				return MoveResult{Accepted: false, Reason: "one_move_per_tick", ServerSeq: r.Seq}, false
+			}
+		}
+	}
+	r.tickMoves = append(r.tickMoves, req)
+	return MoveResult{}, true
+}
+
+// endTick resolves the queued moves, answers their players and broadcasts
+// the combined delta
+func (r *Room) endTick(snapQ chan<- snapshotJob) {
+	if len(r.tickMoves) == 0 {
+		return
+	}
+	moves := r.tickMoves
+	r.tickMoves = nil
+	results, delta := r.ResolveTick(moves)
+	for i, m := range moves {
+		if m.Player != nil {
+			m.Player.sendEnvelope(Envelope{Type: "move_result", MoveResult: &results[i]})
+		}
+	}
+	if delta.ServerSeq != 0 && (len(delta.Added) > 0 || len(delta.Removed) > 0) {
+		r.noteChange(snapQ)
+		r.broadcast(delta)
+	}
+}
+
+// ResolveTick plays a batch of moves as if they were made at the same
+// moment and returns one result per move plus the delta of the whole tick.
+// The outcome does not depend on the order of moves:
+//
+//  1. Moves onto occupied points, and with ForbidSuicide moves that would be
+//     suicide on the board as it was, are rejected.
+//  2. Two or more moves onto the same point all bounce.
+//  3. The remaining stones are placed together.
+//  4. Groups left without liberties that contain no new stone are captured,
+//     all at once, and credited to every enemy color that played next to them.
+//  5. Groups with new stones that still have no liberties are removed.
+//
+// Ko rules do not apply; RuleSet.Validate forbids them in tick mode. Must be
+// called on the room goroutine.
+func (r *Room) ResolveTick(moves []MoveRequest) ([]MoveResult, DeltaUpdate) {
+	results := make([]MoveResult, len(moves))
+	byPoint := make(map[coord][]int)
+	for i, m := range moves {
+		p := coord{X: m.X, Y: m.Y}
+		switch {
+		case m.Color == emptyCell:
+			results[i].Reason = ErrInvalidColor.Error()
+		case !inBounds(m.X, m.Y):
+			results[i].Reason = ErrOutOfBounds.Error()
+		case r.hasStone(m.X, m.Y):
+			results[i].Reason = "occupied"
+		case r.Rules.ForbidSuicide && r.wouldBeSuicide(m):
+			results[i].Reason = "suicide"
+		default:
+			byPoint[p] = append(byPoint[p], i)
+		}
+	}
+
+	// Walk moves in order so results and the delta keep the input order
+	placed := make(map[coord]Color)
+	var accepted []int
+	for i, m := range moves {
+		idx, ok := byPoint[coord{X: m.X, Y: m.Y}]
+		switch {
+		case !ok || results[i].Reason != "":
+		case len(idx) > 1:
+			results[i].Reason = "conflict"
+		default:
+			placed[coord{X: m.X, Y: m.Y}] = m.Color
+			accepted = append(accepted, i)
+		}
+	}
+	if len(placed) == 0 {
+		for i := range results {
+			results[i].ServerSeq = r.Seq
+		}
+		return results, DeltaUpdate{ServerSeq: r.Seq}
+	}
+	for _, i := range accepted {
+		r.setCell(moves[i].X, moves[i].Y, moves[i].Color)
+	}
+
+	// Captures: groups touching a new stone that have no liberties left
+	var removed []Cell
+	visited := make(map[coord]struct{})
+	credits := make(map[Color]int)
+	var suspects []coord
+	for _, i := range accepted {
+		p := coord{X: moves[i].X, Y: moves[i].Y}
+		suspects = append(suspects, p)
+		for _, n := range r.neighbors4(p.X, p.Y) {
+			if _, isNew := placed[n]; !isNew {
+				suspects = append(suspects, n)
+			}
+		}
+	}
+	for _, p := range suspects {
+		color, ok := r.getCell(p.X, p.Y)
+		if _, seen := visited[p]; !ok || seen {
+			continue
+		}
+		comp, hasLiberty := r.bfsGroup(p, color, visited)
+		if hasLiberty || containsAny(comp, placed) {
+			continue
+		}
+		for _, c := range r.attackers(comp, color, placed) {
+			credits[c] += len(comp)
+		}
+		removed = append(removed, comp...)
+	}
+	for _, c := range removed {
+		r.removeCell(c.X, c.Y)
+	}
+	visited = make(map[coord]struct{})
+	for _, i := range accepted {
+		p := coord{X: moves[i].X, Y: moves[i].Y}
+		color, ok := r.getCell(p.X, p.Y)
+		if _, seen := visited[p]; !ok || seen {
+			continue
+		}
+		comp, hasLiberty := r.bfsGroup(p, color, visited)
+		if hasLiberty {
+			continue
+		}
+		for _, c := range comp {
+			r.removeCell(c.X, c.Y)
+		}
+		removed = append(removed, comp...)
+	}
+
+	r.Seq++
+	delta := DeltaUpdate{Removed: removed, ServerSeq: r.Seq}
+	for _, i := range accepted {
+		m := moves[i]
+		results[i].Accepted = true
+		if r.hasStone(m.X, m.Y) {
+			cell := Cell{X: m.X, Y: m.Y, Color: m.Color}
+			results[i].Added = &cell
+			delta.Added = append(delta.Added, cell)
+		}
+		r.statsFor(m.Color).Moves++
+		r.statsFor(m.Color).Stones++
+		r.journal(MoveRecord{Kind: JournalMove, ServerSeq: r.Seq, X: m.X, Y: m.Y, Color: m.Color, Accepted: true})
+	}
+	for _, c := range removed {
+		s := r.statsFor(c.Color)
+		s.Stones--
+		s.Lost++
+	}
+	for color, n := range credits {
+		r.statsFor(color).Captured += n
+	}
+	r.statsChanged()
+	for i := range results {
+		results[i].ServerSeq = r.Seq
+	}
+	return results, delta
+}
+
+func inBounds(x, y int64) bool {
+	_, err := chunkIDFor(x, y)
+	return err == nil
+}
+
+func containsAny(comp []Cell, points map[coord]Color) bool {
+	for _, c := range comp {
+		if _, ok := points[coord{X: c.X, Y: c.Y}]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// attackers lists the enemy colors of new stones next to a group
+func (r *Room) attackers(comp []Cell, color Color, placed map[coord]Color) []Color {
+	seen := make(map[Color]bool)
+	var colors []Color
+	for _, c := range comp {
+		for _, n := range r.neighbors4(c.X, c.Y) {
+			if a, ok := placed[n]; ok && !seen[a] && !r.Rules.allied(a, color) {
+				seen[a] = true
+				colors = append(colors, a)
+			}
+		}
+	}
+	return colors
+}
+
+// wouldBeSuicide reports whether a move alone, on the current board, would
+// leave its group without liberties and capture nothing. The caller has
+// checked that the point is empty. The board is only read, with the move's
+// point seen as taken, so chunks, versions and dirty marks stay as they are.
+func (r *Room) wouldBeSuicide(m MoveRequest) bool {
+	at := coord{X: m.X, Y: m.Y}
+	cellAt := func(p coord) (Color, bool) {
+		if p == at {
+			return m.Color, true
+		}
+		return r.getCell(p.X, p.Y)
+	}
+	if r.groupHasLiberty(at, m.Color, cellAt) {
+		return false
+	}
+	for _, n := range r.neighbors4(m.X, m.Y) {
+		color, ok := r.getCell(n.X, n.Y)
+		if !ok || r.Rules.allied(color, m.Color) {
+			continue
+		}
+		if !r.groupHasLiberty(n, color, cellAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupHasLiberty walks the group at seed on the board as cellAt sees it
+func (r *Room) groupHasLiberty(seed coord, color Color, cellAt func(coord) (Color, bool)) bool {
+	visited := map[coord]struct{}{seed: {}}
+	queue := []coord{seed}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, n := range r.neighbors4(cur.X, cur.Y) {
+			col, ok := cellAt(n)
+			if !ok {
+				return true
+			}
+			if _, seen := visited[n]; !seen && r.Rules.allied(col, color) {
+				visited[n] = struct{}{}
+				queue = append(queue, n)
+			}
+		}
+	}
+	return false
+}
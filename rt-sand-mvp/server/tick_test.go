@@ -0,0 +1,208 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tickRoom() *Room {
+	room := NewRoom()
+	room.Rules.TickMS = 500
+	return room
+}
+
+func TestTickConflictBounces(t *testing.T) {
+	room := tickRoom()
+	results, delta := room.ResolveTick([]MoveRequest{
+		{X: 0, Y: 0, Color: ColorRed},
+		{X: 0, Y: 0, Color: ColorBlue},
+		{X: 3, Y: 3, Color: ColorGreen},
+	})
+	if results[0].Reason != "conflict" || results[1].Reason != "conflict" {
+		t.Fatalf("both moves on one point should bounce, got %+v", results)
+	}
+	if !results[2].Accepted || room.hasStone(0, 0) || !room.hasStone(3, 3) {
+		t.Fatalf("unexpected outcome %+v", results)
+	}
+	if room.Seq != 1 || delta.ServerSeq != 1 || len(delta.Added) != 1 {
+		t.Fatalf("expected one delta for the tick, got %+v", delta)
+	}
+}
+
+func TestTickSimultaneousCapture(t *testing.T) {
+	room := tickRoom()
+	for _, m := range []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: 0, Y: 1, Color: ColorGreen},
+		{X: 0, Y: -1, Color: ColorGreen},
+	} {
+		room.ProcessMove(m)
+	}
+	// Black and red fill the last two liberties of the white stone together
+	results, delta := room.ResolveTick([]MoveRequest{
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: -1, Y: 0, Color: ColorRed},
+	})
+	if !results[0].Accepted || !results[1].Accepted {
+		t.Fatalf("both moves should be accepted, got %+v", results)
+	}
+	if room.hasStone(0, 0) || len(delta.Removed) != 1 || len(delta.Added) != 2 {
+		t.Fatalf("white should be captured once, got %+v", delta)
+	}
+	if room.statsFor(ColorBlack).Captured != 1 || room.statsFor(ColorRed).Captured != 1 {
+		t.Fatalf("both attackers should be credited")
+	}
+}
+
+func TestTickCaptureBeforeSuicide(t *testing.T) {
+	room := tickRoom()
+	// White at (0,0) has one liberty left at (1,0), which is itself
+	// surrounded by white
+	for _, m := range []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+		{X: 0, Y: -1, Color: ColorBlack},
+		{X: 2, Y: 0, Color: ColorWhite},
+		{X: 1, Y: 1, Color: ColorWhite},
+	} {
+		room.ProcessMove(m)
+	}
+	// Black fills (1,0) while white closes (1,-1): the old white stone is
+	// captured first, which gives the new black stone a liberty
+	results, _ := room.ResolveTick([]MoveRequest{
+		{X: 1, Y: -1, Color: ColorWhite},
+		{X: 1, Y: 0, Color: ColorBlack},
+	})
+	if !results[1].Accepted || !room.hasStone(1, 0) || room.hasStone(0, 0) {
+		t.Fatalf("black should capture and survive, got %+v", results)
+	}
+}
+
+func TestTickOrderIndependent(t *testing.T) {
+	setup := []MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: 0, Y: 1, Color: ColorGreen},
+		{X: 5, Y: 5, Color: ColorCyan},
+		{X: 5, Y: 6, Color: ColorRed},
+		{X: 5, Y: 4, Color: ColorRed},
+	}
+	tick := []MoveRequest{
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: -1, Y: 0, Color: ColorRed},
+		{X: 0, Y: -1, Color: ColorBlack},
+		{X: 4, Y: 5, Color: ColorRed},
+		{X: 6, Y: 5, Color: ColorRed},
+		{X: 9, Y: 9, Color: ColorBlue},
+		{X: 9, Y: 9, Color: ColorPink},
+	}
+	reversed := make([]MoveRequest, len(tick))
+	for i, m := range tick {
+		reversed[len(tick)-1-i] = m
+	}
+
+	var hashes []uint64
+	var boards [][]ColorStats
+	for _, moves := range [][]MoveRequest{tick, reversed} {
+		room := tickRoom()
+		for _, m := range setup {
+			room.ProcessMove(m)
+		}
+		room.ResolveTick(moves)
+		hashes = append(hashes, room.hash)
+		boards = append(boards, room.Leaderboard().Colors)
+	}
+	if hashes[0] != hashes[1] || !reflect.DeepEqual(boards[0], boards[1]) {
+		t.Fatalf("outcome depends on move order:\n%+v\n%+v", boards[0], boards[1])
+	}
+}
+
+func TestSuicideCheckLeavesBoardAlone(t *testing.T) {
+	room := tickRoom()
+	room.Rules.ForbidSuicide = true
+	if err := room.AttachStore("suicide", NewMemoryStore()); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	for _, m := range []MoveRequest{
+		{X: -1, Y: 0, Color: ColorBlack},
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: 0, Y: 1, Color: ColorBlack},
+		{X: 0, Y: -1, Color: ColorBlack},
+	} {
+		room.ProcessMove(m)
+	}
+	room.dirty = make(map[ChunkID]struct{})
+	clock, hash := room.chunkClock, room.hash
+
+	results, _ := room.ResolveTick([]MoveRequest{{X: 0, Y: 0, Color: ColorWhite}})
+	if results[0].Reason != "suicide" {
+		t.Fatalf("expected suicide, got %+v", results[0])
+	}
+	if room.chunkClock != clock || room.hash != hash || len(room.dirty) != 0 {
+		t.Fatalf("suicide check changed the board: clock %d->%d, %d dirty", clock, room.chunkClock, len(room.dirty))
+	}
+}
+
+func TestTickOneMovePerClient(t *testing.T) {
+	room := tickRoom()
+	c := &Client{room: room}
+	if _, ok := room.queueTickMove(MoveRequest{Player: c, X: 1, Color: ColorRed}); !ok {
+		t.Fatalf("first move should be queued")
+	}
+	if res, ok := room.queueTickMove(MoveRequest{Player: c, X: 2, Color: ColorRed}); ok || res.Reason != "one_move_per_tick" {
+		t.Fatalf("expected one_move_per_tick, got %+v", res)
+	}
+	room.endTick(nil)
+	if len(room.tickMoves) != 0 || !room.hasStone(1, 0) {
+		t.Fatalf("the queued move was not resolved")
+	}
+	if _, ok := room.queueTickMove(MoveRequest{Player: c, X: 2, Color: ColorRed}); !ok {
+		t.Fatalf("a new tick should accept a move again")
+	}
+}
+
+func TestTickRulesValidate(t *testing.T) {
+	if err := (RuleSet{TickMS: 500, Ko: KoSimple}).Validate(); err == nil {
+		t.Fatalf("ko should be rejected in tick mode")
+	}
+	if err := (RuleSet{TickMS: 10}).Validate(); err == nil {
+		t.Fatalf("too short a tick should be rejected")
+	}
+	if err := (RuleSet{TickMS: 500, ForbidSuicide: true}).Validate(); err != nil {
+		t.Fatalf("valid tick rules rejected: %v", err)
+	}
+}
+
+func TestTickJournalReplay(t *testing.T) {
+	store := NewMemoryStore()
+	original := tickRoom()
+	if err := original.AttachStore("tick", store); err != nil {
+		t.Fatalf("attach store: %v", err)
+	}
+	original.ResolveTick([]MoveRequest{
+		{X: 0, Y: 0, Color: ColorWhite},
+		{X: 0, Y: 1, Color: ColorGreen},
+		{X: 0, Y: -1, Color: ColorGreen},
+	})
+	original.ResolveTick([]MoveRequest{
+		{X: 1, Y: 0, Color: ColorBlack},
+		{X: -1, Y: 0, Color: ColorRed},
+		{X: 7, Y: 7, Color: ColorBlue},
+		{X: 7, Y: 7, Color: ColorPink},
+	})
+	if err := original.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	// The rules come back from the store; the ticks must be replayed whole
+	rebuilt := NewRoom()
+	if err := rebuilt.AttachStore("tick", store); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if rebuilt.Seq != 2 || rebuilt.hash != original.hash {
+		t.Fatalf("replay diverged: seq %d", rebuilt.Seq)
+	}
+	if !reflect.DeepEqual(rebuilt.Leaderboard().Colors, original.Leaderboard().Colors) {
+		t.Fatalf("counters differ after replay")
+	}
+}
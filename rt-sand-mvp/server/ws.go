@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -15,7 +16,15 @@ type Client struct {
 	conn          *websocket.Conn
 	room          *Room
 	send          chan []byte
-	selectedColor *Color // Player's chosen color (nil if not selected yet)
+	selectedColor *Color      // Player's chosen color (nil if not selected yet)
+	binary        bool        // Negotiated the protobuf subprotocol
+	bucket        tokenBucket // move rate limit, owned by the room goroutine
+	session       string      // session token, owned by readPump
+	spectator     bool        // watches the board but cannot play
+
+	// Chunks the client is watching; nil means the whole board. Owned by
+	// the room goroutine.
+	chunks map[ChunkID]struct{}
 }
 
 var upgrader = websocket.Upgrader{
@@ -24,17 +33,63 @@ var upgrader = websocket.Upgrader{
 	// Allow all origins for local/LAN usage; Nginx already restricts access.
 	// Compatible with both LAN and WAN deployments
 	CheckOrigin: func(r *http.Request) bool { return true },
+	// Protobuf is opt-in; clients offering no subprotocol get JSON
+	Subprotocols: []string{SubprotocolProto, SubprotocolJSON},
+	// Packed board states are left uncompressed for permessage-deflate
+	EnableCompression: true,
 }
 
 func ServeWS(roomManager *RoomManager, w http.ResponseWriter, r *http.Request) {
 	// Get room ID from query parameter
 	roomID := r.URL.Query().Get("room")
 	if roomID == "" {
-		roomID = "default"
+		roomID = DefaultRoomID
+	}
+	role := r.URL.Query().Get("role")
+	if role != "" && role != RolePlayer && role != RoleSpectator {
+		http.Error(w, "invalid role", http.StatusBadRequest)
+		return
 	}
 
+	// With fallback=spectator a player who finds the room full watches
+	// instead of being turned away
+	fallback := r.URL.Query().Get("fallback") == RoleSpectator
+
 	// Get or create the room
-	room := roomManager.GetOrCreateRoom(roomID)
+	// Rooms are made with POST /api/rooms; connecting never creates one
+	room, err := roomManager.OpenRoom(roomID)
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	if !room.Access.admits(room.ID, q.Get("password"), q.Get("invite"), time.Now()) {
+		http.Error(w, ErrAccessDenied.Error(), http.StatusForbidden)
+		return
+	}
+	// Replays are watched outside the room and take no seat or slot
+	if q.Get("replay") == "1" {
+		serveReplayWS(room, w, r)
+		return
+	}
+	// A returning player's seat or open tab keeps its slot, so the session
+	// is known before the capacity check
+	session := q.Get("session")
+	if !room.knownSession(session) {
+		session = ""
+	}
+	demoted := false
+	if role != RoleSpectator && room.full(session) {
+		if !fallback {
+			http.Error(w, ErrRoomFull.Error(), http.StatusConflict)
+			return
+		}
+		role, demoted = RoleSpectator, true
+	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -44,57 +99,221 @@ func ServeWS(roomManager *RoomManager, w http.ResponseWriter, r *http.Request) {
 	client := &Client{
 		conn:          conn,
 		room:          room,
-		send:          make(chan []byte, 64),
+		send:          make(chan []byte, 256),
 		selectedColor: nil, // Will be set when player chooses color
+		binary:        conn.Subprotocol() == SubprotocolProto,
+		spectator:     role == RoleSpectator,
 	}
-	room.addClient(client)
+	if !client.spectator {
+		client.session = session // replaced if unknown
+	}
+	// The last slot may have gone since the check above, and a room
+	// hibernated between lookup and join is woken again
+	for err = room.addClient(client); err != nil; err = room.addClient(client) {
+		if errors.Is(err, ErrRoomFull) {
+			if !fallback {
+				client.closeWith(CloseRoomFull, err.Error())
+				return
+			}
+			client.spectator, client.session, demoted = true, "", true
+			continue
+		}
+		if room, err = roomManager.OpenRoom(roomID); err != nil {
+			code := websocket.CloseTryAgainLater
+			if errors.Is(err, ErrRoomNotFound) {
+				code = CloseRoomClosed // closed while we were joining
+			}
+			client.closeWith(code, err.Error())
+			return
+		}
+		client.room = room
+	}
+	if demoted {
+		client.sendError(ErrRoomFull.Error())
+	}
+	// Tell the client which rules and teams it is playing with
+	info := room.Info()
+	client.sendEnvelope(Envelope{Type: "room_info", RoomInfo: &info})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go client.writePump(ctx, cancel)
 	go client.readPump(ctx, cancel)
 }
 
+// serveReplayWS streams the room's history between the from and to query
+// parameters at speed steps per second
+func serveReplayWS(room *Room, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	speed, ok := parseReplaySpeed(q.Get("speed"))
+	if !ok {
+		http.Error(w, "invalid speed", http.StatusBadRequest)
+		return
+	}
+	replay, err := room.replayQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), replayStatus(err))
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("upgrade: %v", err)
+		return
+	}
+	client := &Client{
+		conn:      conn,
+		room:      room,
+		send:      make(chan []byte, 256),
+		binary:    conn.Subprotocol() == SubprotocolProto,
+		spectator: true,
+	}
+	info := room.Info()
+	client.sendEnvelope(Envelope{Type: "room_info", RoomInfo: &info})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	speeds := make(chan float64)
+	go client.writePump(ctx, cancel)
+	go client.replayReadPump(ctx, cancel, speeds)
+	go client.playReplay(ctx, replay, speed, speeds)
+}
+
 func (c *Client) readPump(ctx context.Context, cancel context.CancelFunc) {
 	defer func() {
 		cancel()
 		c.conn.Close()
 		c.room.removeClient(c)
+		c.leaveSession()
 	}()
 	c.conn.SetReadLimit(1 << 16)
+
+	// Issue or resume the session; a returning player gets their seat back.
+	// Spectators hold no seat and get no session.
+	if !c.spectator {
+		reply, ok := c.requestSession(ctx, SessionRequest{Kind: sessionJoin, Player: c, Token: c.session})
+		if !ok {
+			return
+		}
+		c.session = reply.Session.Token
+		c.selectedColor = reply.Session.Color
+		c.sendEnvelope(Envelope{Type: "session", Session: &reply.Session})
+	}
+
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			return
 		}
-		var payload struct {
-			Type  string      `json:"type"`
-			X     json.Number `json:"x"`
-			Y     json.Number `json:"y"`
-			Color int         `json:"color"`
-		}
-		if err := json.Unmarshal(message, &payload); err != nil {
+		payload, err := decodeClientMessage(messageType, message)
+		if err != nil {
 			c.sendError("invalid_payload")
 			continue
 		}
-		
+		if c.spectator && !spectatorAllowed(payload.Type) {
+			c.sendError(ErrSpectator)
+			continue
+		}
+
 		// Handle color selection
 		if payload.Type == "select_color" {
-			if payload.Color < 0 || payload.Color > 255 {
+			if payload.Color < 0 || payload.Color >= int(emptyCell) {
 				c.sendError("invalid_color")
 				continue
 			}
-			selectedColor := Color(payload.Color)
-			c.selectedColor = &selectedColor
+			// The room decides who holds the color
+			reply, ok := c.requestSession(ctx, SessionRequest{Kind: sessionClaim, Token: c.session, Color: Color(payload.Color)})
+			if !ok {
+				return
+			}
+			if reply.Err != nil {
+				result := MoveResult{Accepted: false, Reason: reply.Err.Error(), FreeColors: reply.FreeColors, ServerSeq: c.room.Seq}
+				c.sendEnvelope(Envelope{Type: "move_result", MoveResult: &result})
+				continue
+			}
+			c.selectedColor = reply.Session.Color
 			c.sendEnvelope(Envelope{Type: "color_selected", MoveResult: &MoveResult{Accepted: true, ServerSeq: c.room.Seq}})
 			continue
 		}
-		
+
 		// Handle state request
 		if payload.Type == "get_state" {
 			select {
 			case c.room.StateInbox <- GetStateRequest{Player: c}:
 			case <-ctx.Done():
 				return
+			case <-c.room.done:
+				return
+			}
+			continue
+		}
+
+		// Handle viewport subscription, by cell rectangle or chunk list
+		if payload.Type == "subscribe_region" {
+			chunks := payload.Chunks
+			if chunks == nil {
+				var err error
+				chunks, err = parseRegion(payload.MinX, payload.MinY, payload.MaxX, payload.MaxY)
+				if err != nil {
+					c.sendError(err.Error())
+					continue
+				}
+			} else if len(chunks) > MaxSubscribedChunks {
+				c.sendError(ErrRegionTooLarge.Error())
+				continue
+			}
+			select {
+			case c.room.SubscribeInbox <- SubscribeRequest{Player: c, Chunks: chunks}:
+			case <-ctx.Done():
+				return
+			case <-c.room.done:
+				return
+			}
+			continue
+		}
+
+		// Handle scoring of a rectangle, or of the whole board without one
+		if payload.Type == "get_score" {
+			req := ScoreRequest{Player: c}
+			if payload.MinX != "" || payload.MinY != "" || payload.MaxX != "" || payload.MaxY != "" {
+				rect, err := parseRect(payload.MinX, payload.MinY, payload.MaxX, payload.MaxY)
+				if err != nil {
+					c.sendError(err.Error())
+					continue
+				}
+				req.Region = &rect
+			}
+			select {
+			case c.room.ScoreInbox <- req:
+			case <-ctx.Done():
+				return
+			case <-c.room.done:
+				return
+			}
+			continue
+		}
+
+		if payload.Type == "get_leaderboard" {
+			select {
+			case c.room.BoardInbox <- LeaderboardRequest{Player: c}:
+			case <-ctx.Done():
+				return
+			case <-c.room.done:
+				return
+			}
+			continue
+		}
+
+		// Handle chunk paging; each chunk comes back as its own message
+		if payload.Type == "get_chunks" {
+			if len(payload.Chunks) > MaxChunksPerRequest {
+				c.sendError("too_many_chunks")
+				continue
+			}
+			select {
+			case c.room.ChunksInbox <- ChunksRequest{Player: c, IDs: payload.Chunks}:
+			case <-ctx.Done():
+				return
+			case <-c.room.done:
+				return
 			}
 			continue
 		}
@@ -109,29 +328,31 @@ func (c *Client) readPump(ctx context.Context, cancel context.CancelFunc) {
 			case c.room.ResetInbox <- ResetRequest{Player: c, Color: *c.selectedColor}:
 			case <-ctx.Done():
 				return
+			case <-c.room.done:
+				return
 			}
 			continue
 		}
-		
+
 		// Handle move request - player must have selected a color
 		if c.selectedColor == nil {
 			c.sendError("color_not_selected")
 			continue
 		}
-		
+
 		// Validate that player is using their selected color
 		if payload.Color != int(*c.selectedColor) {
 			c.sendError("must_use_selected_color")
 			continue
 		}
-		
+
 		x, errX := strconv.ParseInt(payload.X.String(), 10, 64)
 		y, errY := strconv.ParseInt(payload.Y.String(), 10, 64)
 		if errX != nil || errY != nil {
 			c.sendError("invalid_coordinate")
 			continue
 		}
-		
+
 		req := MoveRequest{
 			Player: c,
 			X:      x,
@@ -142,8 +363,32 @@ func (c *Client) readPump(ctx context.Context, cancel context.CancelFunc) {
 		case c.room.Inbox <- req:
 		case <-ctx.Done():
 			return
+		case <-c.room.done:
+			return
+		}
+	}
+}
+
+// parseRect parses the corners of a cell rectangle
+func parseRect(minX, minY, maxX, maxY json.Number) (Rect, error) {
+	var bounds [4]int64
+	for i, n := range []json.Number{minX, minY, maxX, maxY} {
+		v, err := strconv.ParseInt(n.String(), 10, 64)
+		if err != nil {
+			return Rect{}, errors.New("invalid_coordinate")
 		}
+		bounds[i] = v
 	}
+	return Rect{MinX: bounds[0], MinY: bounds[1], MaxX: bounds[2], MaxY: bounds[3]}, nil
+}
+
+// parseRegion converts a subscribe_region rectangle into chunk IDs
+func parseRegion(minX, minY, maxX, maxY json.Number) ([]ChunkID, error) {
+	rect, err := parseRect(minX, minY, maxX, maxY)
+	if err != nil {
+		return nil, err
+	}
+	return chunksInRect(rect.MinX, rect.MinY, rect.MaxX, rect.MaxY)
 }
 
 func (c *Client) writePump(ctx context.Context, cancel context.CancelFunc) {
@@ -151,6 +396,10 @@ func (c *Client) writePump(ctx context.Context, cancel context.CancelFunc) {
 		cancel()
 		c.conn.Close()
 	}()
+	messageType := websocket.TextMessage
+	if c.binary {
+		messageType = websocket.BinaryMessage
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -160,7 +409,7 @@ func (c *Client) writePump(ctx context.Context, cancel context.CancelFunc) {
 				return
 			}
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			if err := c.conn.WriteMessage(messageType, msg); err != nil {
 				return
 			}
 		}
@@ -177,7 +426,7 @@ func (c *Client) sendError(reason string) {
 }
 
 func (c *Client) sendEnvelope(env Envelope) {
-	payload, err := json.Marshal(env)
+	payload, err := encodeEnvelope(env, c.binary)
 	if err != nil {
 		log.Printf("send envelope: %v", err)
 		return